@@ -0,0 +1,44 @@
+package jag
+
+import (
+	"fmt"
+)
+
+// GenerateCasts writes IsInstanceLocalFoo, reporting whether an object is an
+// instance of the class, and CastToLocalFoo, returning it as the struct
+// goClassType if it is, for objects returned as a supertype. The struct is
+// registered with jagrt.Wrap, unless the class is generic or an interface,
+// which objects are never just instances of. With NoPanic both also return
+// the error asking the JVM.
+func (s *StringGenerator) GenerateCasts(goClassTypeName, goStructName, goClassType, goTypeParams string) {
+	sig := s.Gen.GetClassSignature()
+	class := sig.GetClassName()
+
+	s.out += fmt.Sprintf("// IsInstance%s reports whether obj is a %s.\n", goClassTypeName, class)
+	if s.NoPanic {
+		s.out += fmt.Sprintf("func IsInstance%s(obj *javabind.Callable) (bool, error) {\n", goClassTypeName)
+		s.out += fmt.Sprintf("\treturn jagrt.IsInstance(obj, \"%s\")\n}\n\n", class)
+	} else {
+		s.out += fmt.Sprintf("func IsInstance%s(obj *javabind.Callable) bool {\n", goClassTypeName)
+		s.out += fmt.Sprintf("\tok, err := jagrt.IsInstance(obj, \"%s\")\n", class)
+		s.out += "\tif err != nil {\n\t\tpanic(err)\n\t}\n\treturn ok\n}\n\n"
+	}
+
+	s.out += fmt.Sprintf("// CastTo%s returns obj as a *%s if it is a %s.\n", goStructName, goStructName, class)
+	if s.NoPanic {
+		s.out += fmt.Sprintf("func CastTo%s%s(obj *javabind.Callable) (*%s, bool, error) {\n", goStructName, goTypeParams, goClassType)
+		s.out += fmt.Sprintf("\tif ok, err := jagrt.IsInstance(obj, \"%s\"); err != nil || !ok {\n\t\treturn nil, false, err\n\t}\n", class)
+		s.out += fmt.Sprintf("\tx := &%s{}\n\tx.Callable = obj\n\treturn x, true, nil\n}\n\n", goClassType)
+	} else {
+		s.out += fmt.Sprintf("func CastTo%s%s(obj *javabind.Callable) (*%s, bool) {\n", goStructName, goTypeParams, goClassType)
+		s.out += fmt.Sprintf("\tok, err := jagrt.IsInstance(obj, \"%s\")\n", class)
+		s.out += "\tif err != nil {\n\t\tpanic(err)\n\t}\n\tif !ok {\n\t\treturn nil, false\n\t}\n"
+		s.out += fmt.Sprintf("\tx := &%s{}\n\tx.Callable = obj\n\treturn x, true\n}\n\n", goClassType)
+	}
+
+	if goTypeParams != "" || sig.GetKind() == Interface || sig.GetKind() == Annotation {
+		return
+	}
+	s.out += fmt.Sprintf("func init() {\n\tjagrt.RegisterClass(\"%s\", func(obj *javabind.Callable) interface{} {\n", class)
+	s.out += fmt.Sprintf("\t\tx := &%s{}\n\t\tx.Callable = obj\n\t\treturn x\n\t})\n}\n\n", goClassType)
+}
@@ -0,0 +1,787 @@
+package jag
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// class file access flags
+const (
+	accPublic     = 0x0001
+	accStatic     = 0x0008
+	accFinal      = 0x0010
+	accBridge     = 0x0040
+	accVarargs    = 0x0080
+	accInterface  = 0x0200
+	accAbstract   = 0x0400
+	accSynthetic  = 0x1000
+	accAnnotation = 0x2000
+	accEnum       = 0x4000
+)
+
+// constant pool tags
+const (
+	constUtf8               = 1
+	constInteger            = 3
+	constFloat              = 4
+	constLong               = 5
+	constDouble             = 6
+	constClass              = 7
+	constString             = 8
+	constFieldref           = 9
+	constMethodref          = 10
+	constInterfaceMethodref = 11
+	constNameAndType        = 12
+	constMethodHandle       = 15
+	constMethodType         = 16
+	constDynamic            = 17
+	constInvokeDynamic      = 18
+	constModule             = 19
+	constPackage            = 20
+)
+
+var ErrNotClassFile = errors.New("not a Java class file")
+
+type cpInfo struct {
+	tag   byte
+	index uint16
+	value interface{}
+}
+
+type attributeInfo struct {
+	name string
+	data []byte
+}
+
+type memberInfo struct {
+	access     uint16
+	name       string
+	descriptor string
+	attributes []attributeInfo
+}
+
+func (m *memberInfo) attribute(name string) []byte {
+	for _, a := range m.attributes {
+		if a.name == name {
+			return a.data
+		}
+	}
+	return nil
+}
+
+type classFile struct {
+	pool       []cpInfo
+	access     uint16
+	thisClass  string
+	superClass string
+	interfaces []string
+	fields     []*memberInfo
+	methods    []*memberInfo
+	attributes []attributeInfo
+}
+
+func (cf *classFile) attribute(name string) []byte {
+	m := memberInfo{attributes: cf.attributes}
+	return m.attribute(name)
+}
+
+type classReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (r *classReader) read(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	r.err = binary.Read(r.r, binary.BigEndian, v)
+}
+
+func (r *classReader) u1() (v uint8) {
+	r.read(&v)
+	return
+}
+
+func (r *classReader) u2() (v uint16) {
+	r.read(&v)
+	return
+}
+
+func (r *classReader) u4() (v uint32) {
+	r.read(&v)
+	return
+}
+
+func (r *classReader) bytes(n int) []byte {
+	b := make([]byte, n)
+	if r.err == nil {
+		_, r.err = io.ReadFull(r.r, b)
+	}
+	return b
+}
+
+func (cf *classFile) utf8(i uint16) string {
+	if int(i) >= len(cf.pool) || cf.pool[i].tag != constUtf8 {
+		return ""
+	}
+	return cf.pool[i].value.(string)
+}
+
+func (cf *classFile) className(i uint16) string {
+	if i == 0 || int(i) >= len(cf.pool) || cf.pool[i].tag != constClass {
+		return ""
+	}
+	return strings.Replace(cf.utf8(cf.pool[i].index), "/", ".", -1)
+}
+
+func readClassFile(in io.Reader) (cf *classFile, err error) {
+	r := &classReader{r: bufio.NewReader(in)}
+	if r.u4() != 0xCAFEBABE {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, ErrNotClassFile
+	}
+	r.u2() // minor version
+	r.u2() // major version
+
+	cf = &classFile{}
+	count := int(r.u2())
+	cf.pool = make([]cpInfo, count)
+	for i := 1; i < count && r.err == nil; i++ {
+		tag := r.u1()
+		cp := &cf.pool[i]
+		cp.tag = tag
+		switch tag {
+		case constUtf8:
+			cp.value = decodeModifiedUtf8(r.bytes(int(r.u2())))
+		case constInteger:
+			cp.value = int32(r.u4())
+		case constFloat:
+			cp.value = math.Float32frombits(r.u4())
+		case constLong:
+			cp.value = int64(uint64(r.u4())<<32 | uint64(r.u4()))
+			i++
+		case constDouble:
+			cp.value = math.Float64frombits(uint64(r.u4())<<32 | uint64(r.u4()))
+			i++
+		case constClass, constString, constMethodType, constModule, constPackage:
+			cp.index = r.u2()
+		case constFieldref, constMethodref, constInterfaceMethodref, constNameAndType, constDynamic, constInvokeDynamic:
+			cp.index = r.u2()
+			r.u2()
+		case constMethodHandle:
+			r.u1()
+			cp.index = r.u2()
+		default:
+			return nil, fmt.Errorf("class file: unknown constant pool tag %d at index %d", tag, i)
+		}
+	}
+
+	cf.access = r.u2()
+	thisClass := r.u2()
+	superClass := r.u2()
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		cf.interfaces = append(cf.interfaces, cf.className(r.u2()))
+	}
+	cf.fields = cf.readMembers(r)
+	cf.methods = cf.readMembers(r)
+	cf.attributes = cf.readAttributes(r)
+	if r.err != nil {
+		return nil, r.err
+	}
+	cf.thisClass = cf.className(thisClass)
+	cf.superClass = cf.className(superClass)
+	return
+}
+
+func (cf *classFile) readMembers(r *classReader) (members []*memberInfo) {
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		m := &memberInfo{}
+		m.access = r.u2()
+		m.name = cf.utf8(r.u2())
+		m.descriptor = cf.utf8(r.u2())
+		m.attributes = cf.readAttributes(r)
+		members = append(members, m)
+	}
+	return
+}
+
+// recordAttribute reads the components in the Record attribute, which are
+// stored like members without access flags.
+func (cf *classFile) recordAttribute() (components []*memberInfo, err error) {
+	data := cf.attribute("Record")
+	if data == nil {
+		return nil, nil
+	}
+	r := &classReader{r: bufio.NewReader(bytes.NewReader(data))}
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		m := &memberInfo{}
+		m.name = cf.utf8(r.u2())
+		m.descriptor = cf.utf8(r.u2())
+		m.attributes = cf.readAttributes(r)
+		components = append(components, m)
+	}
+	return components, r.err
+}
+
+// innerClass returns the access flags of a nested class from the
+// InnerClasses attribute, and whether it is anonymous or local. ok is false
+// if the class is not nested.
+func (cf *classFile) innerClass() (access uint16, anonymous, ok bool) {
+	data := cf.attribute("InnerClasses")
+	for i := 2; i+7 < len(data); i += 8 {
+		if cf.className(binary.BigEndian.Uint16(data[i:])) != cf.thisClass {
+			continue
+		}
+		outer, name := binary.BigEndian.Uint16(data[i+2:]), binary.BigEndian.Uint16(data[i+4:])
+		return binary.BigEndian.Uint16(data[i+6:]), outer == 0 || name == 0, true
+	}
+	return 0, false, false
+}
+
+func (cf *classFile) readAttributes(r *classReader) (attributes []attributeInfo) {
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		name := cf.utf8(r.u2())
+		attributes = append(attributes, attributeInfo{name, r.bytes(int(r.u4()))})
+	}
+	return
+}
+
+// class files store strings as "modified UTF-8", which differs from UTF-8 in
+// its encoding of NUL and of supplementary characters (as surrogate pairs)
+func decodeModifiedUtf8(b []byte) string {
+	runes := make([]rune, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c&0x80 == 0:
+			runes = append(runes, rune(c))
+			i++
+		case c&0xE0 == 0xC0 && i+1 < len(b):
+			runes = append(runes, rune(c&0x1F)<<6|rune(b[i+1]&0x3F))
+			i += 2
+		case c&0xF0 == 0xE0 && i+2 < len(b):
+			runes = append(runes, rune(c&0x0F)<<12|rune(b[i+1]&0x3F)<<6|rune(b[i+2]&0x3F))
+			i += 3
+		default:
+			runes = append(runes, rune(c))
+			i++
+		}
+	}
+	// combine surrogate pairs
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		if r := runes[i]; r >= 0xD800 && r < 0xDC00 && i+1 < len(runes) && runes[i+1] >= 0xDC00 && runes[i+1] < 0xE000 {
+			out = append(out, (r-0xD800)<<10+(runes[i+1]-0xDC00)+0x10000)
+			i++
+			continue
+		}
+		out = append(out, runes[i])
+	}
+	return string(out)
+}
+
+var baseTypes = map[byte]string{
+	'B': "byte",
+	'C': "char",
+	'D': "double",
+	'F': "float",
+	'I': "int",
+	'J': "long",
+	'S': "short",
+	'Z': "boolean",
+	'V': "void",
+}
+
+// signatureReader turns field/method descriptors and generic signatures into
+// type names written the way javap prints them
+type signatureReader struct {
+	s   string
+	pos int
+}
+
+func (r *signatureReader) peek() byte {
+	if r.pos >= len(r.s) {
+		return 0
+	}
+	return r.s[r.pos]
+}
+
+func (r *signatureReader) next() byte {
+	c := r.peek()
+	r.pos++
+	return c
+}
+
+func (r *signatureReader) readType() (string, error) {
+	c := r.next()
+	if t, ok := baseTypes[c]; ok {
+		return t, nil
+	}
+	switch c {
+	case '[':
+		t, err := r.readType()
+		return t + "[]", err
+	case 'T':
+		end := strings.IndexByte(r.s[r.pos:], ';')
+		if end < 0 {
+			return "", r.errorf("unterminated type variable")
+		}
+		name := r.s[r.pos : r.pos+end]
+		r.pos += end + 1
+		return name, nil
+	case 'L':
+		return r.readClassType()
+	}
+	return "", r.errorf("unexpected %q", c)
+}
+
+func (r *signatureReader) readClassType() (string, error) {
+	var name string
+	for {
+		c := r.next()
+		switch c {
+		case 0:
+			return "", r.errorf("unterminated class type")
+		case ';':
+			return name, nil
+		case '/':
+			name += "."
+		case '.':
+			// the type arguments of the enclosing class are dropped, only
+			// the nested class is bound
+			if i := strings.Index(name, "<"); i >= 0 {
+				name = name[:i]
+			}
+			name += "$"
+		case '<':
+			args, err := r.readTypeArgs()
+			if err != nil {
+				return "", err
+			}
+			name += "<" + strings.Join(args, ", ") + ">"
+		default:
+			name += string(c)
+		}
+	}
+}
+
+func (r *signatureReader) readTypeArgs() (args []string, err error) {
+	for r.peek() != '>' {
+		var arg string
+		switch r.peek() {
+		case 0:
+			return nil, r.errorf("unterminated type arguments")
+		case '*':
+			r.next()
+			arg = "?"
+		case '+':
+			r.next()
+			arg, err = r.readType()
+			arg = "? extends " + arg
+		case '-':
+			r.next()
+			arg, err = r.readType()
+			arg = "? super " + arg
+		default:
+			arg, err = r.readType()
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	r.next()
+	return
+}
+
+// readTypeParams reads a formal type parameter list, eg
+// <T:Ljava/lang/Object;U::Ljava/lang/Comparable<TU;>;>
+func (r *signatureReader) readTypeParams() (tps TypeParams, err error) {
+	if r.peek() != '<' {
+		return
+	}
+	r.next()
+	for r.peek() != '>' {
+		end := strings.IndexByte(r.s[r.pos:], ':')
+		if end <= 0 {
+			return nil, r.errorf("bad type parameter")
+		}
+		tp := TypeParam{Name: r.s[r.pos : r.pos+end]}
+		r.pos += end
+		// class bound, which may be empty, then interface bounds
+		for r.peek() == ':' {
+			r.next()
+			if r.peek() == ':' {
+				continue
+			}
+			var bound string
+			if bound, err = r.readType(); err != nil {
+				return
+			}
+			tp.Bounds = append(tp.Bounds, parseJavaTypeName(bound))
+		}
+		if len(tp.Bounds) == 1 && tp.Bounds[0].String() == "java.lang.Object" {
+			tp.Bounds = nil
+		}
+		tps = append(tps, tp)
+	}
+	r.next()
+	return
+}
+
+func (r *signatureReader) readMethod() (tps TypeParams, params []string, ret string, throws []string, err error) {
+	if tps, err = r.readTypeParams(); err != nil {
+		return
+	}
+	if r.next() != '(' {
+		err = r.errorf("expected (")
+		return
+	}
+	for r.peek() != ')' {
+		var t string
+		if t, err = r.readType(); err != nil {
+			return
+		}
+		params = append(params, t)
+	}
+	r.next()
+	if ret, err = r.readType(); err != nil {
+		return
+	}
+	for r.peek() == '^' {
+		r.next()
+		var t string
+		if t, err = r.readType(); err != nil {
+			return
+		}
+		throws = append(throws, t)
+	}
+	return
+}
+
+func (r *signatureReader) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("class file: bad signature %q at %d: %s", r.s, r.pos, fmt.Sprintf(format, a...))
+}
+
+func (cf *classFile) exceptions(m *memberInfo) (list []string) {
+	data := m.attribute("Exceptions")
+	for i := 2; i+1 < len(data); i += 2 {
+		list = append(list, cf.className(binary.BigEndian.Uint16(data[i:])))
+	}
+	return
+}
+
+// constantValue returns the ConstantValue of a field of type t as a Java
+// literal, the way javap -constants writes it, or "" if it has none.
+func (cf *classFile) constantValue(f *memberInfo, t string) string {
+	data := f.attribute("ConstantValue")
+	if len(data) != 2 {
+		return ""
+	}
+	i := binary.BigEndian.Uint16(data)
+	if i == 0 || int(i) >= len(cf.pool) {
+		return ""
+	}
+	switch v := cf.pool[i].value; cf.pool[i].tag {
+	case constInteger:
+		switch t {
+		case "boolean":
+			return strconv.FormatBool(v.(int32) != 0)
+		case "char":
+			return javaQuote([]uint16{uint16(v.(int32))}, '\'')
+		}
+		return strconv.FormatInt(int64(v.(int32)), 10)
+	case constLong:
+		return strconv.FormatInt(v.(int64), 10) + "l"
+	case constFloat:
+		return strconv.FormatFloat(float64(v.(float32)), 'g', -1, 32) + "f"
+	case constDouble:
+		return strconv.FormatFloat(v.(float64), 'g', -1, 64) + "d"
+	case constString:
+		return javaQuote(utf16.Encode([]rune(cf.utf8(cf.pool[i].index))), '"')
+	}
+	return ""
+}
+
+// parameter names are only available when compiled with -parameters
+func (cf *classFile) parameterNames(m *memberInfo) (names []string) {
+	data := m.attribute("MethodParameters")
+	for i := 1; i+3 < len(data); i += 4 {
+		names = append(names, cf.utf8(binary.BigEndian.Uint16(data[i:])))
+	}
+	return
+}
+
+func modifiers(access uint16) (z string) {
+	if access&accPublic != 0 {
+		z += "public "
+	}
+	if access&accStatic != 0 {
+		z += "static "
+	}
+	if access&accFinal != 0 {
+		z += "final "
+	}
+	if access&accAbstract != 0 {
+		z += "abstract "
+	}
+	return
+}
+
+// ReadClassFile decodes a compiled Java class and fills in c the same way
+// ClassSig.Parse does from javap output. Only public members are recorded.
+func ReadClassFile(r io.Reader, c *ClassSig) error {
+	cf, err := readClassFile(r)
+	if err != nil {
+		return err
+	}
+
+	if cf.access&accPublic == 0 {
+		return nil
+	}
+	// protected nested classes are public in the class file
+	innerAccess, anonymous, nested := cf.innerClass()
+	if nested && (anonymous || innerAccess&accPublic == 0) {
+		return nil
+	}
+
+	// the class signature has the type parameters of a generic class and
+	// the type arguments of its superclass
+	var classSig *signatureReader
+	if data := cf.attribute("Signature"); len(data) == 2 {
+		classSig = &signatureReader{s: cf.utf8(binary.BigEndian.Uint16(data))}
+		if c.TypeParams, err = classSig.readTypeParams(); err != nil {
+			return err
+		}
+	}
+
+	c.ClassName = cf.thisClass
+	c.Line = modifiers(cf.access) + "class " + c.ClassName + c.TypeParams.String()
+	if cf.access&accInterface != 0 {
+		c.Line = modifiers(cf.access&^accAbstract) + "interface " + c.ClassName + c.TypeParams.String()
+	}
+	if i := strings.LastIndex(c.ClassName, "."); i >= 0 {
+		c.PackageName = c.ClassName[:i]
+	}
+
+	switch {
+	case cf.access&accAnnotation != 0:
+		c.Kind = Annotation
+	case cf.access&accInterface != 0:
+		c.Kind = Interface
+	case cf.access&accEnum != 0:
+		c.Kind = Enum
+	case cf.superClass == "java.lang.Record":
+		c.Kind = Record
+	case cf.access&accAbstract != 0:
+		c.Kind = AbstractClass
+	}
+
+	// the class signature has the superclass and interfaces with their type
+	// arguments, an interface has java.lang.Object as its superclass
+	super, interfaces := cf.superClass, cf.interfaces
+	if classSig != nil {
+		if t, err := classSig.readType(); err == nil {
+			super = t
+			var generic []string
+			for range cf.interfaces {
+				t, err := classSig.readType()
+				if err != nil {
+					break
+				}
+				generic = append(generic, t)
+			}
+			if len(generic) == len(cf.interfaces) {
+				interfaces = generic
+			}
+		}
+	}
+	c.Interfaces = interfaces
+	if cf.access&accInterface != 0 {
+		if len(interfaces) > 0 {
+			c.Extends = interfaces[0]
+		}
+	} else if cf.superClass != "java.lang.Object" {
+		c.Extends = super
+	}
+
+	if c.Kind == Record {
+		components, err := cf.recordAttribute()
+		if err != nil {
+			return err
+		}
+		c.Components = make([]*ClassSigField, 0, len(components))
+		for _, f := range components {
+			desc := f.descriptor
+			if data := f.attribute("Signature"); len(data) == 2 {
+				desc = cf.utf8(binary.BigEndian.Uint16(data))
+			}
+			t, err := (&signatureReader{s: desc}).readType()
+			if err != nil {
+				return err
+			}
+			c.Components = append(c.Components, &ClassSigField{
+				Name:  f.name,
+				Type:  t,
+				Final: true,
+				Line:  t + " " + f.name,
+			})
+		}
+	}
+
+	for _, f := range cf.fields {
+		if f.access&accPublic == 0 || f.access&accSynthetic != 0 {
+			continue
+		}
+		desc := f.descriptor
+		if data := f.attribute("Signature"); len(data) == 2 {
+			desc = cf.utf8(binary.BigEndian.Uint16(data))
+		}
+		t, err := (&signatureReader{s: desc}).readType()
+		if err != nil {
+			c.Skipped = append(c.Skipped, modifiers(f.access)+f.name+" "+desc)
+			continue
+		}
+		line := modifiers(f.access) + t + " " + f.name
+		value := cf.constantValue(f, t)
+		if value != "" {
+			line += " = " + value
+		}
+		c.Fields = append(c.Fields, &ClassSigField{
+			Name:   f.name,
+			Type:   t,
+			Static: f.access&accStatic != 0,
+			Final:  f.access&accFinal != 0,
+			Value:  value,
+			Enum:   f.access&accEnum != 0,
+			Line:   line,
+		})
+	}
+
+	for _, m := range cf.methods {
+		if m.access&accPublic == 0 || m.access&(accSynthetic|accBridge) != 0 || m.name == "<clinit>" {
+			continue
+		}
+		desc := m.descriptor
+		if data := m.attribute("Signature"); len(data) == 2 {
+			desc = cf.utf8(binary.BigEndian.Uint16(data))
+		}
+		typeParams, types, ret, throws, err := (&signatureReader{s: desc}).readMethod()
+		if err != nil {
+			c.Skipped = append(c.Skipped, modifiers(m.access)+m.name+desc)
+			continue
+		}
+		if len(throws) == 0 {
+			throws = cf.exceptions(m)
+		}
+		// the signature of a constructor leaves out the enclosing instance
+		// of an inner class, which the descriptor has
+		if m.name == "<init>" && desc != m.descriptor {
+			if _, descTypes, _, _, err := (&signatureReader{s: m.descriptor}).readMethod(); err == nil && len(descTypes) > len(types) {
+				types = append(descTypes[:len(descTypes)-len(types)], types...)
+			}
+		}
+		if m.access&accVarargs != 0 && len(types) > 0 {
+			last := len(types) - 1
+			types[last] = strings.TrimSuffix(types[last], "[]") + "..."
+		}
+
+		names := cf.parameterNames(m)
+		params := make(Params, len(types))
+		for i := range params {
+			if i < len(names) && names[i] != "" {
+				params[i].Name = names[i]
+			} else {
+				params[i].Name = fmt.Sprintf("%c", 'a'+i)
+			}
+			params[i].Type = typeParams.Erase(types[i])
+		}
+
+		line := modifiers(m.access)
+		if len(typeParams) > 0 {
+			line += typeParams.String() + " "
+		}
+		if m.name == "<init>" {
+			line += c.ClassName
+		} else {
+			line += ret + " " + m.name
+		}
+		line += "(" + strings.Join(types, ", ") + ")"
+		if len(throws) > 0 {
+			line += " throws " + strings.Join(throws, ", ")
+		}
+
+		if m.name == "<init>" {
+			c.Constructors = append(c.Constructors, &ClassSigConstructor{
+				Params:     params,
+				Throws:     len(throws) > 0,
+				Exceptions: typeParams.EraseAll(throws),
+				Line:       line,
+				TypeParams: typeParams,
+			})
+		} else {
+			c.Methods = append(c.Methods, &ClassSigMethod{
+				Name:       m.name,
+				Params:     params,
+				Return:     typeParams.Erase(ret),
+				Throws:     len(throws) > 0,
+				Exceptions: typeParams.EraseAll(throws),
+				Line:       line,
+				Static:     m.access&accStatic != 0,
+				TypeParams: typeParams,
+			})
+		}
+	}
+	c.checkRecord()
+	if nested && innerAccess&accStatic == 0 && c.Kind != Interface && c.Kind != Annotation {
+		c.setOuter(enclosingClass(c.ClassName))
+	}
+	return nil
+}
+
+// ClassFile is a Parser component that reads a compiled class file instead
+// of javap output. It takes the place of Statements in NewClassFileParser.
+type ClassFile struct {
+	*ClassSig
+}
+
+func (c *ClassFile) Scan() error {
+	return c.Parse()
+}
+
+func (c *ClassFile) Parse() error {
+	return ReadClassFile(c.Parser, c.ClassSig)
+}
+
+func (c *ClassFile) GetStatement() (string, error) {
+	return "", io.EOF
+}
+
+func (c *ClassFile) ScopeDepth() int {
+	return 0
+}
+
+func (c *ClassFile) Position() (line, column int) {
+	return 0, 0
+}
+
+func NewClassFileParser(h *ParserHandle, t *Tokens, c *ClassFile, p ParamParser, r io.Reader) Parser {
+	o := &struct {
+		*Tokens
+		*ClassFile
+		ParamParser
+		io.Reader
+	}{t, c, p, r}
+	h.Parser = o
+	return o
+}
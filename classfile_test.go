@@ -0,0 +1,149 @@
+package jag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestReadClassFile(t *testing.T) {
+	file, err := os.Open("tests/java_example/out/production/java_example/local/Foo.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	c := &ClassSig{}
+	if err := ReadClassFile(file, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.ClassName != "local.Foo" || c.PackageName != "local" || c.Extends != "local.SuperFoo" || c.Kind != Class {
+		t.Fatalf("bad %s %s package %s extends %s", c.Kind, c.ClassName, c.PackageName, c.Extends)
+	}
+
+	if len(c.Constructors) != 1 || !c.Constructors[0].Throws || c.Constructors[0].Params[0].Type != "boolean" {
+		t.Fatalf("bad constructors %v", c.Constructors)
+	}
+
+	methods := make(map[string]*ClassSigMethod)
+	for _, m := range c.Methods {
+		methods[m.Name] = m
+	}
+	if m := methods["Method1"]; m == nil || m.Return != "java.util.List<java.lang.String>" || !m.Throws ||
+		m.Params[1].Type != "java.util.List<java.lang.String>" {
+		t.Fatalf("bad Method1 %v", m)
+	}
+	if m := methods["Method3"]; m == nil || m.Params[0].Type != "java.lang.String..." {
+		t.Fatalf("bad Method3 %v", m)
+	}
+	if m := methods["Method5"]; m == nil || m.Return != "java.util.Map<java.lang.String, java.lang.String>" {
+		t.Fatalf("bad Method5 %v", m)
+	}
+	if m := methods["Method8"]; m == nil || !m.Static || m.Return != "int" {
+		t.Fatalf("bad Method8 %v", m)
+	}
+	if m := methods["Method10"]; m == nil || m.Return != "int[][]" {
+		t.Fatalf("bad Method10 %v", m)
+	}
+
+	if len(c.Fields) != 2 || c.Fields[0].Name != "answer" || c.Fields[1].Type != "local.Bar" {
+		t.Fatalf("bad fields %v", c.Fields)
+	}
+}
+
+func TestReadEnumClassFile(t *testing.T) {
+	// public enum Color { RED; public static final Color DEFAULT = RED; }
+	var b bytes.Buffer
+	u2 := func(v int) { binary.Write(&b, binary.BigEndian, uint16(v)) }
+	utf8 := func(s string) {
+		b.WriteByte(constUtf8)
+		u2(len(s))
+		b.WriteString(s)
+	}
+	b.Write([]byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52})
+	u2(8)
+	utf8("local/Color")
+	b.WriteByte(constClass)
+	u2(1)
+	utf8("java/lang/Enum")
+	b.WriteByte(constClass)
+	u2(3)
+	utf8("RED")
+	utf8("Llocal/Color;")
+	utf8("DEFAULT")
+	u2(accPublic | accFinal | accEnum)
+	u2(2)
+	u2(4)
+	u2(0)
+	u2(2)
+	for _, f := range []struct {
+		access, name int
+	}{{accPublic | accStatic | accFinal | accEnum, 5}, {accPublic | accStatic | accFinal, 7}} {
+		u2(f.access)
+		u2(f.name)
+		u2(6)
+		u2(0)
+	}
+	u2(0)
+	u2(0)
+
+	c := &ClassSig{}
+	if err := ReadClassFile(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Kind != Enum || len(c.Fields) != 2 || !c.Fields[0].Enum || c.Fields[1].Enum {
+		t.Fatalf("bad %s fields %+v %+v", c.Kind, c.Fields[0], c.Fields[1])
+	}
+}
+
+func TestReadClassFileSkipped(t *testing.T) {
+	// public class Foo { public int count; public ? bad; } with a broken
+	// descriptor for bad
+	var b bytes.Buffer
+	u2 := func(v int) { binary.Write(&b, binary.BigEndian, uint16(v)) }
+	utf8 := func(s string) {
+		b.WriteByte(constUtf8)
+		u2(len(s))
+		b.WriteString(s)
+	}
+	b.Write([]byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52})
+	u2(9)
+	utf8("local/Foo")
+	b.WriteByte(constClass)
+	u2(1)
+	utf8("java/lang/Object")
+	b.WriteByte(constClass)
+	u2(3)
+	utf8("count")
+	utf8("I")
+	utf8("bad")
+	utf8("Llocal/Bad")
+	u2(accPublic)
+	u2(2)
+	u2(4)
+	u2(0)
+	u2(2)
+	for _, f := range []struct {
+		name, desc int
+	}{{5, 6}, {7, 8}} {
+		u2(accPublic)
+		u2(f.name)
+		u2(f.desc)
+		u2(0)
+	}
+	u2(0)
+	u2(0)
+
+	c := &ClassSig{}
+	if err := ReadClassFile(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Fields) != 1 || c.Fields[0].Name != "count" {
+		t.Fatalf("bad fields %+v", c.Fields)
+	}
+	if len(c.Skipped) != 1 || c.Skipped[0] != "public bad Llocal/Bad" {
+		t.Fatalf("bad skipped %q", c.Skipped)
+	}
+}
@@ -0,0 +1,121 @@
+package jag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type nameRegexp struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// NameRules shortens Java class names on their way to Go type names, and
+// keeps track of the names given out so collisions can be reported. A rules
+// file has one rule per line:
+//
+//	# Java package alias, com.amazonaws.services.ec2.model.Instance -> Ec2Instance
+//	alias com.amazonaws.services.ec2.model Ec2
+//	# regexp replacement on the Java name, as regexp.ReplaceAllString
+//	regex ^org\.example\.internal\. Internal.
+//	# suffix to drop from Go names, FooImpl -> Foo
+//	strip-suffix Impl
+//
+// Aliases are applied first (the longest matching package wins), then the
+// regexps in order, then suffixes are stripped from the resulting Go name.
+type NameRules struct {
+	aliases  map[string]string
+	regexps  []nameRegexp
+	suffixes []string
+	// Go name -> Java names given it
+	names map[string]map[string]bool
+}
+
+func NewNameRules() *NameRules {
+	return &NameRules{aliases: make(map[string]string), names: make(map[string]map[string]bool)}
+}
+
+func LoadNameRules(r io.Reader) (*NameRules, error) {
+	n := NewNameRules()
+	lineScanner := bufio.NewScanner(r)
+	for line := 1; lineScanner.Scan(); line++ {
+		fields := strings.Fields(lineScanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case fields[0] == "alias" && len(fields) == 3:
+			n.aliases[fields[1]] = fields[2]
+		case fields[0] == "regex" && (len(fields) == 2 || len(fields) == 3):
+			re, err := regexp.Compile(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("name rules line %d: %s", line, err)
+			}
+			replacement := ""
+			if len(fields) == 3 {
+				replacement = fields[2]
+			}
+			n.regexps = append(n.regexps, nameRegexp{re, replacement})
+		case fields[0] == "strip-suffix" && len(fields) == 2:
+			n.suffixes = append(n.suffixes, fields[1])
+		default:
+			return nil, fmt.Errorf("name rules line %d: bad rule %q", line, lineScanner.Text())
+		}
+	}
+	return n, lineScanner.Err()
+}
+
+// rewrite applies the alias and regexp rules to a Java class name.
+func (n *NameRules) rewrite(s string) string {
+	best := ""
+	for pkg := range n.aliases {
+		if strings.HasPrefix(s, pkg+".") && len(pkg) > len(best) {
+			best = pkg
+		}
+	}
+	if best != "" {
+		s = n.aliases[best] + strings.TrimPrefix(s, best)
+	}
+	for _, r := range n.regexps {
+		s = r.re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+func (n *NameRules) stripSuffix(z string) string {
+	for _, suffix := range n.suffixes {
+		if strings.HasSuffix(z, suffix) && len(z) > len(suffix) {
+			z = strings.TrimSuffix(z, suffix)
+		}
+	}
+	return z
+}
+
+func (n *NameRules) record(javaName, goName string) {
+	if n.names[goName] == nil {
+		n.names[goName] = make(map[string]bool)
+	}
+	n.names[goName][javaName] = true
+}
+
+// Collisions lists the Go names given to more than one Java class, as
+// "GoName: java.Name1, java.Name2".
+func (n *NameRules) Collisions() (list []string) {
+	for goName, javaNames := range n.names {
+		if len(javaNames) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(javaNames))
+		for javaName := range javaNames {
+			names = append(names, javaName)
+		}
+		sort.Strings(names)
+		list = append(list, goName+": "+strings.Join(names, ", "))
+	}
+	sort.Strings(list)
+	return
+}
@@ -0,0 +1,154 @@
+package jag
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClassPath finds class files by class name in a list of directories and
+// jars, like the java -cp option.
+type ClassPath struct {
+	dirs []string
+	jars []*zip.ReadCloser
+}
+
+// NewClassPath opens a class path given as a list of directories and jar
+// files separated by os.PathListSeparator.
+func NewClassPath(path string) (*ClassPath, error) {
+	cp := &ClassPath{}
+	for _, entry := range filepath.SplitList(path) {
+		if entry == "" {
+			continue
+		}
+		if strings.HasSuffix(entry, ".jar") || strings.HasSuffix(entry, ".zip") {
+			jar, err := zip.OpenReader(entry)
+			if err != nil {
+				cp.Close()
+				return nil, err
+			}
+			cp.jars = append(cp.jars, jar)
+		} else {
+			cp.dirs = append(cp.dirs, entry)
+		}
+	}
+	return cp, nil
+}
+
+// Open returns the class file for a class name such as local.Foo.
+func (cp *ClassPath) Open(className string) (io.ReadCloser, error) {
+	name := strings.Replace(className, ".", "/", -1) + ".class"
+	for _, dir := range cp.dirs {
+		file, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil {
+			return file, nil
+		}
+	}
+	for _, jar := range cp.jars {
+		for _, f := range jar.File {
+			if f.Name == name {
+				return f.Open()
+			}
+		}
+	}
+	return nil, fmt.Errorf("class %s not found in class path", className)
+}
+
+func (cp *ClassPath) Close() error {
+	for _, jar := range cp.jars {
+		jar.Close()
+	}
+	cp.jars = nil
+	return nil
+}
+
+// WalkClosure calls visit for each class reachable from roots through the
+// dependencies visit returns, breadth first. Dependencies more than maxDepth
+// steps from a root, or not in a package starting with one of the allow
+// prefixes, are not visited. A negative maxDepth means no limit, and an
+// empty allow list allows every package.
+func WalkClosure(roots []string, maxDepth int, allow []string, visit func(className string) (deps []string, err error)) error {
+	type item struct {
+		className string
+		depth     int
+	}
+
+	seen := make(map[string]bool)
+	queue := make([]item, 0, len(roots))
+	for _, r := range roots {
+		seen[r] = true
+		queue = append(queue, item{r, 0})
+	}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		deps, err := visit(next.className)
+		if err != nil {
+			return err
+		}
+		if maxDepth >= 0 && next.depth >= maxDepth {
+			continue
+		}
+		for _, d := range deps {
+			if seen[d] || !allowedPackage(d, allow) {
+				continue
+			}
+			seen[d] = true
+			queue = append(queue, item{d, next.depth + 1})
+		}
+	}
+	return nil
+}
+
+// ReferencedClasses returns the classes used in the declaration of a class,
+// by its superclass, interfaces and the types of its members, including type
+// arguments. These are the dependencies -closure follows.
+func ReferencedClasses(sig ClassSigInterface) (classes []string) {
+	found := make(map[string]bool)
+	add := func(types ...string) {
+		for _, t := range types {
+			for _, name := range parseJavaTypeName(t).ClassNames() {
+				if _, ok := sig.GetTypeParams().lookup(name); ok || found[name] {
+					continue
+				}
+				found[name] = true
+				classes = append(classes, name)
+			}
+		}
+	}
+	if sig.GetExtends() != "" {
+		add(sig.GetExtends())
+	}
+	add(sig.GetInterfaces()...)
+	for _, c := range sig.GetConstructors() {
+		add(c.Params.Types()...)
+	}
+	for _, m := range sig.GetMethods() {
+		add(m.Params.Types()...)
+		add(m.Return)
+	}
+	for _, f := range sig.GetFields() {
+		add(f.Type)
+	}
+	for _, c := range sig.GetComponents() {
+		add(c.Type)
+	}
+	return
+}
+
+func allowedPackage(className string, allow []string) bool {
+	if len(allow) == 0 {
+		return true
+	}
+	for _, prefix := range allow {
+		if className == prefix || strings.HasPrefix(className, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package jag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalkClosure(t *testing.T) {
+	deps := map[string][]string{
+		"local.Foo":      {"local.Bar", "local.SuperFoo", "java.lang.Object"},
+		"local.Bar":      {"local.Baz"},
+		"local.SuperFoo": {"local.Foo"},
+		"local.Baz":      {"local.Qux"},
+	}
+
+	var visited []string
+	err := WalkClosure([]string{"local.Foo"}, 2, []string{"local"}, func(className string) ([]string, error) {
+		visited = append(visited, className)
+		return deps[className], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"local.Foo", "local.Bar", "local.SuperFoo", "local.Baz"}
+	if len(visited) != len(want) {
+		t.Fatalf("visited %v", visited)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Fatalf("visited %v", visited)
+		}
+	}
+}
+
+func TestClassPath(t *testing.T) {
+	cp, err := NewClassPath("tests/java_example/out/production/java_example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+
+	file, err := cp.Open("local.Bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	c := &ClassSig{}
+	if err := ReadClassFile(file, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.ClassName != "local.Bar" {
+		t.Fatal(c.ClassName)
+	}
+
+	if _, err := cp.Open("local.Missing"); err == nil {
+		t.Fatal("expected error for missing class")
+	}
+}
+
+func TestReferencedClasses(t *testing.T) {
+	sig, err := ParseClass(strings.NewReader(`public class local.Foo<T> extends local.Base<T> implements java.lang.Comparable<local.Foo<T>> {
+  public local.Foo(local.Bar[], T);
+  public java.util.List<? extends local.Baz> list(java.lang.String, int);
+  public <U extends local.Qux> U get(U);
+  public local.Foo$Inner inner;
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "local.Base java.lang.Comparable local.Foo local.Bar java.lang.String java.util.List local.Baz local.Qux local.Foo$Inner"
+	if got := strings.Join(ReferencedClasses(sig), " "); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -8,21 +8,135 @@ import (
 	"strings"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"bytes"
+	"archive/zip"
+	"path/filepath"
+	"sort"
 	"github.com/timob/commentfilter"
 )
 
+var (
+	inputFilename = flag.String("in", "", "javap output file")
+	classFilename = flag.String("class", "", "read a compiled .class file instead of javap output")
+	jarFilename = flag.String("jar", "", "generate a Go file for each public class in a jar file")
+	jarPrefix = flag.String("prefix", "", "only generate classes from jar in packages starting with this prefix")
+	closure = flag.Bool("closure", false, "generate the classes given as arguments and every class they depend on")
+	classPath = flag.String("cp", "", "class path of directories and jars to find classes in -closure mode")
+	closureDepth = flag.Int("depth", -1, "maximum dependency depth to follow in -closure mode, -1 for no limit")
+	closureAllow = flag.String("allow", "", "comma separated package prefixes to follow in -closure mode, defaults to the packages of the root classes")
+	outputDir = flag.String("out", ".", "output directory for generated files in -jar and -closure mode")
+	srcFilename = flag.String("src", "", "set the source file name")
+	packageName = flag.String("pkg", "gojvm_gen_package", "set the Go package name")
+	outputTypeDependency = flag.Bool("d", false, "display type dependency")
+	typeFilter = flag.String("filter", "", "filter out functions/methods by parameter/return types")
+	trim = flag.String("trim", "", "prefix to trim from generated type names")
+	abstractClassesFileName = flag.String("abstract", "", "file with names of abstract/interface classes, overriding the detected ones, -name for a class that is not")
+	conversionsFileName = flag.String("conv", "", "JSON file with type conversions to use over the built in ones")
+	overloadNaming = flag.String("overload", "index", "how to name overloaded methods: index (Read, Read2), types (ReadString, ReadInt) or names (parameter names from -src)")
+	renameFileName = flag.String("rename", "", "file mapping Java method signatures to Go names")
+	namesFileName = flag.String("names", "", "file with rules for shortening Java class names")
+	noPanic = flag.Bool("nopanic", false, "make every generated function return an error instead of panicking")
+	enumClasses = flag.String("enums", "", "comma separated enum classes used by the class generated, for single class mode where only its own kind is known")
+	recordClasses = flag.String("records", "", "comma separated record classes used by the class generated, for single class mode where only its own kind is known")
+	genericClasses = flag.String("generics", "", "comma separated generic classes used by the class generated, for single class mode, others are used without type arguments")
+	exceptionsFileName = flag.String("exceptions", "", "file to write Go error types for the exceptions thrown to, keeping the ones already in it, defaults to exceptions.go in the output directory in -jar and -closure mode")
+)
+
+var conversions *jag.ConversionConfig
+var namer jag.OverloadNamer
+var nameRules = jag.NewNameRules()
+
 func main() {
-	inputFilename := flag.String("in", "", "javap output file")
-	srcFilename := flag.String("src", "", "set the source file name")
-	packageName := flag.String("pkg", "gojvm_gen_package", "set the Go package name")
-	outputTypeDependency := flag.Bool("d", false, "display type dependency")
-	typeFilter := flag.String("filter", "", "filter out functions/methods by parameter/return types")
-	trim := flag.String("trim", "", "prefix to trim from generated type names")
-	abstractClassesFileName := flag.String("abstract", "", "file with names of abstract/interface classes")
 	flag.Parse()
 
+	var abstractClassListFile io.Reader
+	if *abstractClassesFileName != "" {
+		file, err := os.Open(*abstractClassesFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		abstractClassListFile = file
+	}
+	abstractClasses := jag.NewAbstractClassList(abstractClassListFile)
+	if *enumClasses != "" {
+		for _, name := range strings.Split(*enumClasses, ",") {
+			abstractClasses.AddClass(strings.TrimSpace(name), jag.Enum)
+		}
+	}
+	if *recordClasses != "" {
+		for _, name := range strings.Split(*recordClasses, ",") {
+			abstractClasses.AddClass(strings.TrimSpace(name), jag.Record)
+		}
+	}
+	if *genericClasses != "" {
+		for _, name := range strings.Split(*genericClasses, ",") {
+			abstractClasses.AddGeneric(strings.TrimSpace(name))
+		}
+	}
+
+	if *conversionsFileName != "" {
+		file, err := os.Open(*conversionsFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		conversions, err = jag.LoadConversions(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", *conversionsFileName, err)
+		}
+	}
+
+	var err error
+	namer, err = jag.NewOverloadNamer(*overloadNaming)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *renameFileName != "" {
+		file, err := os.Open(*renameFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		namer, err = jag.NewRenameMap(file, namer)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", *renameFileName, err)
+		}
+	}
+
+	if *namesFileName != "" {
+		file, err := os.Open(*namesFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		nameRules, err = jag.LoadNameRules(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", *namesFileName, err)
+		}
+	}
+	defer reportCollisions()
+
+	if *jarFilename != "" {
+		generateJar(abstractClasses)
+		return
+	}
+
+	if *closure {
+		generateClosure(flag.Args(), abstractClasses)
+		return
+	}
+
 	var javapReader io.Reader
-	if *inputFilename != "" {
+	if *classFilename != "" {
+		file, err := os.Open(*classFilename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		javapReader = file
+	} else if *inputFilename != "" {
 		file, err := os.Open(*inputFilename)
 		if err != nil {
 			log.Fatal(err)
@@ -43,16 +157,39 @@ func main() {
 		srcReader = file
 	}
 
-	var abstractClassListFile io.Reader
-	if *abstractClassesFileName != "" {
-		file, err := os.Open(*abstractClassesFileName)
+	var handle *jag.ParserHandle
+	var javapSig *jag.ClassSig
+	if *classFilename != "" {
+		var err error
+		handle, javapSig, err = parseClassFile(javapReader)
 		if err != nil {
 			log.Fatal(err)
 		}
-		defer file.Close()
-		abstractClassListFile = file
+	} else {
+		handle, javapSig = parseJavap(javapReader)
+	}
+	reportSkipped(javapSig)
+	if javapSig.ClassName == "" {
+		log.Fatal("no public class found")
 	}
 
+	if srcReader != nil {
+		mergeSource(javapSig, srcReader)
+	}
+
+	addClass(abstractClasses, javapSig)
+	out, callables := generate(handle, abstractClasses)
+	if *outputTypeDependency {
+		fmt.Println(strings.Join(callables, " "))
+	} else {
+		fmt.Print(out)
+		if *exceptionsFileName != "" {
+			writeExceptions(*exceptionsFileName, jag.ThrownClasses(handle.Parser))
+		}
+	}
+}
+
+func parseJavap(javapReader io.Reader) (*jag.ParserHandle, *jag.ClassSig) {
 	handle := &jag.ParserHandle{}
 	javapSig := &jag.ClassSig{Parser: handle}
 	parser := jag.NewParser(
@@ -64,85 +201,352 @@ func main() {
 		commentfilter.NewCommentFilter("Signature:", "\n", `"`, `\`, commentfilter.NewCommentFilter("Compiled from", "\n", `"`, `\`, javapReader)),
 	)
 
-	parser.Scan()
+	if err := parser.Scan(); err != nil {
+		log.Fatal(err)
+	}
+	return handle, javapSig
+}
 
-	if srcReader != nil {
-		handle := &jag.ParserHandle{}
-		srcSig := &jag.ClassSig{Parser: handle}
-		srcParser := jag.NewParser(
-			handle,
-			jag.NewStatements(handle),
-			&jag.Tokens{Parser: handle},
-			srcSig,
-			&jag.SrcParams{Parser: handle},
-			commentfilter.NewCommentFilter("//", "\n", `"`, `\`, commentfilter.NewCommentFilter("/*", "*/", `"`, `\`, srcReader)),
-		)
-		srcParser.Scan()
-
-		cParamNames := make(map[string]int)
-		for i, c := range srcSig.Constructors {
-			cParamNames[strings.Join(c.Params.TypeClassNames(), "-")] = i
-		}
-		mParamNames := make(map[string]int)
-		for i, m := range srcSig.Methods {
-			mParamNames[m.Name + strings.Join(m.Params.TypeClassNames(), "-")] = i
-		}
-		for _, c := range javapSig.Constructors {
-			if v, ok := cParamNames[strings.Join(c.Params.TypeClassNames(), "-")]; ok {
-				for i := range c.Params {
-					c.Params[i].Name = srcSig.Constructors[v].Params[i].Name
-				}
-				c.Line = srcSig.Constructors[v].Line
+func parseClassFile(classReader io.Reader) (*jag.ParserHandle, *jag.ClassSig, error) {
+	handle := &jag.ParserHandle{}
+	javapSig := &jag.ClassSig{Parser: handle}
+	classFile := &jag.ClassFile{ClassSig: javapSig}
+	parser := jag.NewClassFileParser(
+		handle,
+		&jag.Tokens{Parser: handle},
+		classFile,
+		&jag.JavapParams{Parser: handle},
+		classReader,
+	)
+	err := parser.Scan()
+	return handle, javapSig, err
+}
+
+// mergeSource copies parameter names, declaration lines and Javadoc from the
+// Java source over the ones derived from javap.
+func mergeSource(javapSig *jag.ClassSig, srcReader io.Reader) {
+	src, err := ioutil.ReadAll(srcReader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	docs, err := jag.ReadJavadoc(bytes.NewReader(src))
+	if err != nil {
+		log.Fatal(err)
+	}
+	srcReader = bytes.NewReader(src)
+
+	handle := &jag.ParserHandle{}
+	srcSig := &jag.ClassSig{Parser: handle}
+	srcParser := jag.NewParser(
+		handle,
+		jag.NewStatements(handle),
+		&jag.Tokens{Parser: handle},
+		srcSig,
+		&jag.SrcParams{Parser: handle},
+		commentfilter.NewCommentFilter("//", "\n", `"`, `\`, commentfilter.NewCommentFilter("/*", "*/", `"`, `\`, srcReader)),
+	)
+	if err := srcParser.Scan(); err != nil {
+		log.Fatal(err)
+	}
+
+	cParamNames := make(map[string]int)
+	for i, c := range srcSig.Constructors {
+		cParamNames[strings.Join(c.Params.TypeClassNames(), "-")] = i
+	}
+	mParamNames := make(map[string]int)
+	for i, m := range srcSig.Methods {
+		mParamNames[m.Name + strings.Join(m.Params.TypeClassNames(), "-")] = i
+	}
+	for _, c := range javapSig.Constructors {
+		if v, ok := cParamNames[strings.Join(c.Params.TypeClassNames(), "-")]; ok {
+			for i := range c.Params {
+				c.Params[i].Name = srcSig.Constructors[v].Params[i].Name
 			}
+			c.Line = srcSig.Constructors[v].Line
+			c.Doc = jag.FormatJavadoc(docs[c.Line])
 		}
-		for _, m := range javapSig.Methods {
-			if v, ok := mParamNames[m.Name + strings.Join(m.Params.TypeClassNames(), "-")]; ok {
-				for i := range m.Params {
-					m.Params[i].Name = srcSig.Methods[v].Params[i].Name
-				}
-				m.Line = srcSig.Methods[v].Line
+	}
+	for _, m := range javapSig.Methods {
+		if v, ok := mParamNames[m.Name + strings.Join(m.Params.TypeClassNames(), "-")]; ok {
+			for i := range m.Params {
+				m.Params[i].Name = srcSig.Methods[v].Params[i].Name
 			}
+			m.Line = srcSig.Methods[v].Line
+			m.Doc = jag.FormatJavadoc(docs[m.Line])
 		}
 	}
+	fieldDocs := make(map[string]string)
+	for _, f := range srcSig.Fields {
+		fieldDocs[f.Name] = docs[f.Line]
+	}
+	for _, f := range javapSig.Fields {
+		f.Doc = jag.FormatJavadoc(fieldDocs[f.Name])
+	}
+	javapSig.Doc = jag.FormatJavadoc(docs[srcSig.Line])
+}
 
-	genHandle := &jag.GeneratorHandle{}
+// generate returns the Go source for the class parsed into handle, and the
+// callable types it depends on.
+func generate(handle *jag.ParserHandle, abstractClasses *jag.AbstractClassList) (string, []string) {
+	gen, list := newGenerator(handle, abstractClasses)
+	gen.Generate()
+	return gen.Output(), list.ListCallables()
+}
 
-	var t jag.TranslatorInterface
-	var list *jag.CallableList
+// generator is the generator jagen uses, put together from the parts of jag.
+type generator struct {
+	jag.TranslatorInterface
+	jag.ImportListInterface
+	*jag.ClassSigFilter
+	*jag.StringGenerator
+	*jag.AbstractClassList
+}
 
-	if *outputTypeDependency {
-		list = jag.NewCallableList(jag.NewTranslator(genHandle, *trim))
-		t = list
-	} else {
-		t = jag.NewTranslator(genHandle, *trim)
-	}
+func newGenerator(handle *jag.ParserHandle, abstractClasses *jag.AbstractClassList) (*generator, *jag.CallableList) {
+	genHandle := &jag.GeneratorHandle{}
 
-	importList := jag.NewImportList(t)
-	t = importList
+	translator := jag.NewTranslator(genHandle, *trim)
+	translator.Names = nameRules
+	if conversions != nil {
+		translator.AddConversions(conversions)
+	}
+	list := jag.NewCallableList(translator)
+	importList := jag.NewImportList(list)
 
 	filter := jag.NewClassSigFilter(handle.Parser, *typeFilter)
 	handle.Parser = filter
 
-	gen := &struct {
-		jag.TranslatorInterface
-		jag.ImportListInterface
-		*jag.ClassSigFilter
-		*jag.StringGenerator
-		*jag.AbstractClassList
-		} {
-		t,
+	gen := &generator{
+		importList,
 		importList,
 		filter,
-		&jag.StringGenerator{Gen: genHandle, PkgName: *packageName},
-		jag.NewAbstractClassList(abstractClassListFile),
+		&jag.StringGenerator{Gen: genHandle, PkgName: *packageName, Namer: namer, NoPanic: *noPanic},
+		abstractClasses,
 	}
 	genHandle.Generator = gen
+	return gen, list
+}
 
-	gen.Generate()
+// exceptionsFile returns the file for the exception error types in -jar and
+// -closure mode.
+func exceptionsFile() string {
+	if *exceptionsFileName != "" {
+		return *exceptionsFileName
+	}
+	return filepath.Join(*outputDir, "exceptions.go")
+}
+
+// writeExceptions writes the Go error types for the exception classes to
+// fileName, along with those already in it.
+func writeExceptions(fileName string, classes []string) {
+	if file, err := os.Open(fileName); err == nil {
+		existing, err := jag.ReadExceptionClasses(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", fileName, err)
+		}
+		classes = append(classes, existing...)
+	} else if !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	sort.Strings(classes)
+	for i := 1; i < len(classes); i++ {
+		if classes[i] == classes[i-1] {
+			classes = append(classes[:i], classes[i+1:]...)
+			i--
+		}
+	}
+	if len(classes) == 0 {
+		return
+	}
+
+	handle, _ := parseJavap(strings.NewReader(""))
+	gen, _ := newGenerator(handle, jag.NewAbstractClassList(nil))
+	gen.GenerateExceptions(classes)
+	if err := ioutil.WriteFile(fileName, []byte(gen.Output()), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generateJar(abstractClasses *jag.AbstractClassList) {
+	jar, err := zip.OpenReader(*jarFilename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer jar.Close()
+
+	// all classes are parsed before generating so the kinds of all of them
+	// are known
+	var handles []*jag.ParserHandle
+	var sigs []*jag.ClassSig
+	for _, entry := range jag.JarClasses(&jar.Reader, *jarPrefix) {
+		// anonymous and local classes can't be used from outside
+		if jag.IsAnonymousClass(entry.Name) {
+			continue
+		}
+
+		data, err := readZipFile(entry)
+		if err != nil {
+			log.Fatal(err)
+		}
+		handle, sig, err := parseClassFile(bytes.NewReader(data))
+		if err != nil {
+			log.Fatalf("%s: %s", entry.Name, err)
+		}
+		reportSkipped(sig)
+		if sig.ClassName == "" {
+			continue
+		}
+		addClass(abstractClasses, sig)
+		handles = append(handles, handle)
+		sigs = append(sigs, sig)
+	}
+
+	dependencies := make(map[string]bool)
+	var thrown []string
+	for i, handle := range handles {
+		out, callables := generate(handle, abstractClasses)
+		for _, c := range callables {
+			dependencies[c] = true
+		}
+		if !*outputTypeDependency {
+			writeGoFile(sigs[i].ClassName, out)
+			thrown = append(thrown, jag.ThrownClasses(handle.Parser)...)
+		}
+	}
+	if !*outputTypeDependency {
+		writeExceptions(exceptionsFile(), thrown)
+	}
 
 	if *outputTypeDependency {
-		fmt.Println(strings.Join(list.ListCallables(), " "))
+		list := make([]string, 0, len(dependencies))
+		for c := range dependencies {
+			list = append(list, c)
+		}
+		fmt.Println(strings.Join(list, " "))
+	}
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func writeGoFile(className, out string) {
+	fileName := filepath.Join(*outputDir, jag.GoFileName(className, *trim))
+	if err := ioutil.WriteFile(fileName, []byte(out), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generateClosure(roots []string, abstractClasses *jag.AbstractClassList) {
+	if len(roots) == 0 {
+		log.Fatal("-closure needs at least one root class argument")
+	}
+
+	cp, err := jag.NewClassPath(*classPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cp.Close()
+
+	var allow []string
+	if *closureAllow != "" {
+		allow = strings.Split(*closureAllow, ",")
 	} else {
-		fmt.Print(gen.Output())
+		for _, r := range roots {
+			if i := strings.LastIndex(r, "."); i >= 0 {
+				allow = append(allow, r[:i])
+			}
+		}
+	}
+
+	// the walk finds the classes and their kinds, the classes are generated
+	// after it when the kinds of all of them are known
+	translator := jag.NewTranslator(nil, *trim)
+	if conversions != nil {
+		translator.AddConversions(conversions)
+	}
+	var classNames []string
+	handles := make(map[string]*jag.ParserHandle)
+	referenced := make(map[string]bool)
+	err = jag.WalkClosure(roots, *closureDepth, allow, func(className string) ([]string, error) {
+		// a class missing from the class path is left out of the closure
+		file, err := cp.Open(className)
+		if err != nil {
+			log.Printf("%s, skipping", err)
+			return nil, nil
+		}
+		defer file.Close()
+
+		handle, sig, err := parseClassFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", className, err)
+		}
+		reportSkipped(sig)
+		if sig.ClassName == "" {
+			if len(sig.Skipped) == 0 {
+				log.Printf("%s is not a public class, skipping", className)
+			}
+			return nil, nil
+		}
+
+		addClass(abstractClasses, sig)
+		classNames = append(classNames, className)
+		handles[className] = handle
+		var deps []string
+		for _, c := range jag.ReferencedClasses(jag.NewClassSigFilter(handle.Parser, *typeFilter)) {
+			if !translator.IsGoJVMType(c) && !translator.IsConverted(c) {
+				referenced[c] = true
+				deps = append(deps, c)
+			}
+		}
+		return deps, nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var thrown []string
+	for _, className := range classNames {
+		out, _ := generate(handles[className], abstractClasses)
+		writeGoFile(className, out)
+		thrown = append(thrown, jag.ThrownClasses(handles[className].Parser)...)
+	}
+	writeExceptions(exceptionsFile(), thrown)
+
+	for c := range referenced {
+		if handles[c] == nil {
+			log.Printf("%s is used but was not generated", c)
+		}
+	}
+}
+
+// addClass records the kind of a parsed class, and whether it is generic.
+func addClass(abstractClasses *jag.AbstractClassList, sig *jag.ClassSig) {
+	abstractClasses.AddClass(sig.ClassName, sig.Kind)
+	if len(sig.TypeParams) > 0 {
+		abstractClasses.AddGeneric(sig.ClassName)
+	}
+}
+
+// reportSkipped logs the declarations the parser could not bind.
+func reportSkipped(sig *jag.ClassSig) {
+	for _, line := range sig.Skipped {
+		log.Printf("not supported, skipping: %s", line)
+	}
+}
+
+func reportCollisions() {
+	collisions := nameRules.Collisions()
+	for _, c := range collisions {
+		log.Printf("Go type name used for more than one Java class: %s", c)
+	}
+	if len(collisions) > 0 {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/timob/jag"
+)
+
+func TestMergeSource(t *testing.T) {
+	sig, err := jag.ParseClass(strings.NewReader(`public class local.Foo {
+  public local.Foo(int);
+  public java.lang.String toString();
+  public void put(java.util.Map<java.lang.String, java.lang.Integer>);
+  public java.lang.String name;
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeSource(sig, strings.NewReader(`package local;
+
+/** A foo. */
+@Deprecated
+public class Foo {
+	/** The name. */
+	@Nullable
+	public String name;
+
+	/** Makes a foo. */
+	public Foo(@Size(max = 5) int size) {
+	}
+
+	/** Returns the name. */
+	@Override
+	public String toString() {
+		return name;
+	}
+
+	/** Puts m. */
+	@Deprecated @SuppressWarnings("unchecked")
+	public void put(@Deprecated java.util.Map<String,Integer> m) {
+	}
+}
+`))
+	if sig.Doc != "A foo." {
+		t.Errorf("class doc %q", sig.Doc)
+	}
+	if sig.Constructors[0].Doc != "Makes a foo." || sig.Constructors[0].Params[0].Name != "size" {
+		t.Errorf("constructor %+v", sig.Constructors[0])
+	}
+	for i, want := range []string{"Returns the name.", "Puts m."} {
+		if m := sig.Methods[i]; m.Doc != want {
+			t.Errorf("%s doc %q, want %q", m.Name, m.Doc, want)
+		}
+	}
+	if sig.Methods[1].Params[0].Name != "m" {
+		t.Errorf("put params %v", sig.Methods[1].Params)
+	}
+	if sig.Fields[0].Doc != "The name." {
+		t.Errorf("field doc %q", sig.Fields[0].Doc)
+	}
+}
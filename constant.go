@@ -0,0 +1,146 @@
+package jag
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// javaQuote writes UTF-16 code units as a Java string or char literal, with
+// everything but printable ASCII escaped.
+func javaQuote(units []uint16, quote byte) string {
+	z := []byte{quote}
+	for _, u := range units {
+		switch {
+		case u == '\b':
+			z = append(z, `\b`...)
+		case u == '\t':
+			z = append(z, `\t`...)
+		case u == '\n':
+			z = append(z, `\n`...)
+		case u == '\f':
+			z = append(z, `\f`...)
+		case u == '\r':
+			z = append(z, `\r`...)
+		case u == '\\' || u == uint16(quote):
+			z = append(z, '\\', byte(u))
+		case u < 0x20 || u >= 0x7f:
+			z = append(z, fmt.Sprintf(`\u%04x`, u)...)
+		default:
+			z = append(z, byte(u))
+		}
+	}
+	return string(append(z, quote))
+}
+
+// javaUnquote returns the value of a Java string or char literal.
+func javaUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || s[0] != '"' && s[0] != '\'' {
+		return "", errors.New("not a literal")
+	}
+	s = s[1 : len(s)-1]
+	var units []uint16
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			units = append(units, utf16.Encode([]rune{r})...)
+			i += size - 1
+			continue
+		}
+		if i++; i == len(s) {
+			return "", errors.New("bad escape")
+		}
+		switch c := s[i]; c {
+		case 'b':
+			units = append(units, '\b')
+		case 't':
+			units = append(units, '\t')
+		case 'n':
+			units = append(units, '\n')
+		case 'f':
+			units = append(units, '\f')
+		case 'r':
+			units = append(units, '\r')
+		case 's':
+			units = append(units, ' ')
+		case '"', '\'', '\\':
+			units = append(units, uint16(c))
+		case 'u':
+			for i < len(s) && s[i] == 'u' {
+				i++
+			}
+			if i+4 > len(s) {
+				return "", errors.New("bad unicode escape")
+			}
+			u, err := strconv.ParseUint(s[i:i+4], 16, 16)
+			if err != nil {
+				return "", err
+			}
+			units = append(units, uint16(u))
+			i += 3
+		default:
+			// octal, up to \377
+			j := i
+			for j < len(s) && j-i < 3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			u, err := strconv.ParseUint(s[i:j], 8, 8)
+			if err != nil {
+				return "", errors.New("bad escape")
+			}
+			units = append(units, uint16(u))
+			i = j - 1
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// goConstantTypes are the Go types a constant can be declared with.
+var goConstantTypes = map[string]bool{
+	"bool": true, "string": true, "rune": true, "byte": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// goConstant returns the Go literal for the value of a Java constant of the
+// Java type javaType, declared with the Go type goType. ok is false if the
+// value is not a literal or can't be a Go constant, eg NaN.
+func goConstant(javaType, goType, value string) (literal string, ok bool) {
+	if !goConstantTypes[goType] {
+		return "", false
+	}
+	switch javaType {
+	case "boolean":
+		return value, value == "true" || value == "false"
+	case "java.lang.String":
+		s, err := javaUnquote(value)
+		return strconv.Quote(s), err == nil && value[0] == '"'
+	case "char":
+		s, err := javaUnquote(value)
+		if err != nil || value[0] != '\'' || len([]rune(s)) != 1 {
+			return "", false
+		}
+		return strconv.QuoteRune([]rune(s)[0]), true
+	case "byte", "short", "int", "long":
+		value = strings.Replace(strings.TrimRight(value, "lL"), "_", "", -1)
+		v, err := strconv.ParseInt(value, 0, 64)
+		return strconv.FormatInt(v, 10), err == nil
+	case "float", "double":
+		bits := 64
+		if javaType == "float" {
+			bits = 32
+		}
+		value = strings.Replace(strings.TrimRight(value, "fFdD"), "_", "", -1)
+		v, err := strconv.ParseFloat(value, bits)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'g', -1, bits), true
+	}
+	return "", false
+}
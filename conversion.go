@@ -0,0 +1,163 @@
+package jag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Conversion describes how values of a Java object type are converted to and
+// from a Go type.
+//
+// Names in Go and in the converter constructors can be qualified with their
+// import path, eg "github.com/google/uuid.UUID", in which case they are
+// written as uuid.UUID and the import is added to the generated file.
+type Conversion struct {
+	// Java type name, may be a pattern as used by path.Match, eg "java.util.*".
+	// Java names are separated by dots rather than slashes, so * matches dots
+	// too and "java.util.*" also matches java.util.concurrent.Future.
+	Java string `json:"java"`
+	// Go type template, with a %s for each Java type argument, eg "[]%s"
+	Go string `json:"go"`
+	// converter constructor names, by default javabind.NewGoToJava<Class> and
+	// javabind.NewJavaToGo<Class>
+	GoToJava string `json:"goToJava,omitempty"`
+	JavaToGo string `json:"javaToGo,omitempty"`
+	// import paths the generated file needs when the conversion is used
+	Imports []string `json:"imports,omitempty"`
+}
+
+var qualifiedNameRe = regexp.MustCompile(`([\w.~-]+/)+[\w.~-]*[\w~-]\.[A-Za-z_]\w*`)
+
+// unqualify replaces import path qualified names in s with package qualified
+// names, returning the import paths used.
+func unqualify(s string) (z string, imports []string) {
+	z = qualifiedNameRe.ReplaceAllStringFunc(s, func(name string) string {
+		dot := strings.LastIndex(name, ".")
+		importPath := name[:dot]
+		imports = append(imports, importPath)
+		return packageName(importPath) + name[dot:]
+	})
+	return
+}
+
+// packageName guesses the package name of an import path from its last
+// element, skipping major version suffixes like /v2 and cutting ones like
+// .v2, so gopkg.in/yaml.v2 gives yaml.
+func packageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, ".go")
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.NewReplacer("-", "_", "~", "_").Replace(name)
+}
+
+func (c *Conversion) GoType() string {
+	z, _ := unqualify(c.Go)
+	return z
+}
+
+// Converter returns the constructor name for the converter in the direction
+// given by prefix, goToJavaPrefix or javaToGoPrefix, or "" for the default.
+func (c *Conversion) Converter(prefix string) (z string) {
+	if prefix == goToJavaPrefix {
+		z, _ = unqualify(c.GoToJava)
+	} else if prefix == javaToGoPrefix {
+		z, _ = unqualify(c.JavaToGo)
+	}
+	return
+}
+
+// AllImports returns Imports and the import paths of qualified names.
+func (c *Conversion) AllImports() (list []string) {
+	list = append(list, c.Imports...)
+	for _, s := range []string{c.Go, c.GoToJava, c.JavaToGo} {
+		_, imports := unqualify(s)
+		list = append(list, imports...)
+	}
+	return
+}
+
+func (c *Conversion) matches(javaType string) bool {
+	if c.Java == javaType {
+		return true
+	}
+	ok, _ := path.Match(c.Java, javaType)
+	return ok
+}
+
+// ConversionConfig is the format of a conversion config file, eg:
+//
+//	{
+//		"types": {"short": "int16"},
+//		"conversions": [
+//			{"java": "java.util.UUID", "go": "uuid.UUID",
+//			 "goToJava": "uuidconv.NewGoToJava", "javaToGo": "uuidconv.NewJavaToGo",
+//			 "imports": ["github.com/google/uuid", "example.com/uuidconv"]}
+//		]
+//	}
+//
+// Types are passed to javabind as is, like the built in typeMap. Conversions
+// are used in place of the built in objectConversions.
+type ConversionConfig struct {
+	Types       map[string]string `json:"types"`
+	Conversions []*Conversion     `json:"conversions"`
+}
+
+// ConverterRegistry is the set of conversions a Translator uses on top of
+// the built in objectConversions.
+type ConverterRegistry struct {
+	conversions []*Conversion
+}
+
+func NewConverterRegistry() *ConverterRegistry {
+	return &ConverterRegistry{}
+}
+
+// Register adds a conversion. Conversions registered later take precedence.
+func (r *ConverterRegistry) Register(c *Conversion) {
+	r.conversions = append(r.conversions, c)
+}
+
+// Lookup returns the conversion for a Java type name, or nil if there is none.
+func (r *ConverterRegistry) Lookup(javaType string) *Conversion {
+	for i := len(r.conversions) - 1; i >= 0; i-- {
+		if r.conversions[i].matches(javaType) {
+			return r.conversions[i]
+		}
+	}
+	return nil
+}
+
+// LoadConversions reads a ConversionConfig. Types and conversions need both
+// a Java and a Go type, and conversion patterns must be valid, the error
+// names the first entry that isn't.
+func LoadConversions(r io.Reader) (c *ConversionConfig, err error) {
+	c = new(ConversionConfig)
+	if err = json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	for java, goType := range c.Types {
+		if java == "" || goType == "" {
+			return nil, fmt.Errorf("types: %q: empty Java or Go type", java)
+		}
+	}
+	for i, conv := range c.Conversions {
+		if conv == nil || conv.Java == "" || conv.Go == "" {
+			return nil, fmt.Errorf("conversions[%d]: empty Java or Go type", i)
+		}
+		if _, err := path.Match(conv.Java, ""); err != nil {
+			return nil, fmt.Errorf("conversions[%d]: java %q: %s", i, conv.Java, err)
+		}
+	}
+	return
+}
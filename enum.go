@@ -0,0 +1,69 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateEnum writes the Go type of an enum, a named int type with a
+// constant for each Java constant, and its converters. The methods of the
+// enum are on goStructName, the Object method returns it for a value.
+func (s *StringGenerator) GenerateEnum(goClassTypeName, goStructName string) {
+	sig := s.Gen.GetClassSignature()
+	namesVar := strings.ToLower(goClassTypeName[:1]) + goClassTypeName[1:] + "Names"
+
+	var names []string
+	for _, field := range sig.GetFields() {
+		if field.Enum {
+			names = append(names, field.Name)
+		}
+	}
+
+	if sig.GetDoc() != "" {
+		s.printDoc(sig.GetDoc(), "")
+	} else {
+		s.out += fmt.Sprintf("// %s is a constant of the Java enum %s.\n", goClassTypeName, sig.GetClassName())
+	}
+	s.out += fmt.Sprintf("type %s int\n\n", goClassTypeName)
+	if len(names) > 0 {
+		s.out += "const (\n"
+		for i, name := range names {
+			s.out += "\t" + goClassTypeName + capitalize(name)
+			if i == 0 {
+				s.out += " " + goClassTypeName + " = iota"
+			}
+			s.out += "\n"
+		}
+		s.out += ")\n\n"
+	}
+	s.out += fmt.Sprintf("var %s = []string{", namesVar)
+	for i, name := range names {
+		if i != 0 {
+			s.out += ", "
+		}
+		s.out += "\"" + name + "\""
+	}
+	s.out += "}\n\n"
+
+	s.out += "// String returns the name of the Java constant.\n"
+	s.out += fmt.Sprintf("func (e %s) String() string {\n", goClassTypeName)
+	s.out += fmt.Sprintf("\tif e < 0 || int(e) >= len(%s) {\n", namesVar)
+	s.out += fmt.Sprintf("\t\treturn \"%s(\" + strconv.Itoa(int(e)) + \")\"\n\t}\n", goClassTypeName)
+	s.out += fmt.Sprintf("\treturn %s[e]\n}\n\n", namesVar)
+
+	s.out += "// Object returns the Java constant for e, to call its methods.\n"
+	s.out += fmt.Sprintf("func (e %s) Object() (*%s, error) {\n", goClassTypeName, goStructName)
+	s.out += fmt.Sprintf("\tobj, err := jagrt.EnumObject(\"%s\", e.String())\n", sig.GetClassName())
+	s.out += "\tif err != nil {\n\t\treturn nil, err\n\t}\n"
+	s.out += fmt.Sprintf("\tx := &%s{}\n\tx.Callable = obj\n\treturn x, nil\n}\n\n", goStructName)
+
+	s.out += fmt.Sprintf("// NewGoToJava%s returns the converter of %s values to Java.\n", goClassTypeName, goClassTypeName)
+	s.out += fmt.Sprintf("func NewGoToJava%s() javabind.Converter {\n", goClassTypeName)
+	s.out += fmt.Sprintf("\treturn jagrt.NewGoToJavaEnum(\"%s\")\n}\n\n", sig.GetClassName())
+	s.out += fmt.Sprintf("// NewJavaToGo%s returns the converter of Java constants to %s.\n", goClassTypeName, goClassTypeName)
+	s.out += fmt.Sprintf("func NewJavaToGo%s() javabind.Converter {\n", goClassTypeName)
+	s.out += fmt.Sprintf("\treturn jagrt.NewJavaToGoEnum(%s)\n}\n\n", namesVar)
+
+	s.out += "func init() {\n"
+	s.out += fmt.Sprintf("\tjagrt.RegisterConverter(%s(0), NewGoToJava%s, NewJavaToGo%s)\n}\n\n", goClassTypeName, goClassTypeName, goClassTypeName)
+}
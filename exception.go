@@ -0,0 +1,71 @@
+package jag
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+)
+
+// ThrownClasses returns the exception classes in the throws clauses of the
+// constructors and methods of sig, sorted and with type variables erased.
+func ThrownClasses(sig ClassSigInterface) (classes []string) {
+	found := make(map[string]bool)
+	add := func(exceptions []string) {
+		for _, e := range exceptions {
+			e = sig.GetTypeParams().Erase(e)
+			if !found[e] {
+				found[e] = true
+				classes = append(classes, e)
+			}
+		}
+	}
+	for _, c := range sig.GetConstructors() {
+		add(c.Exceptions)
+	}
+	for _, m := range sig.GetMethods() {
+		add(m.Exceptions)
+	}
+	sort.Strings(classes)
+	return
+}
+
+var registerExceptionPattern = regexp.MustCompile(`jagrt\.RegisterException\("([^"]+)"`)
+
+// ReadExceptionClasses returns the exception classes in a file written by
+// GenerateExceptions, so it can be written again with more classes.
+func ReadExceptionClasses(r io.Reader) (classes []string, err error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range registerExceptionPattern.FindAllSubmatch(src, -1) {
+		classes = append(classes, string(m[1]))
+	}
+	return
+}
+
+// exceptionTypeName returns the name of the Go error type for an exception
+// class.
+func (s *StringGenerator) exceptionTypeName(class string) string {
+	return s.Gen.javaNameToGoName(class) + "Error"
+}
+
+// GenerateExceptions writes a Go file with an error type for each exception
+// class, eg JavaIoIOExceptionError for java.io.IOException. The types are
+// registered with jagrt so the errors returned by generated methods have
+// them, and errors.As finds the type of any superclass.
+func (s *StringGenerator) GenerateExceptions(classes []string) {
+	s.out = "package " + s.PkgName + "\n\nimport \"" + jagrtImport + "\"\n\n"
+	for _, class := range classes {
+		name := s.exceptionTypeName(class)
+		s.out += fmt.Sprintf("// %s is returned for a %s, or a subclass of it,\n// thrown from Java.\n", name, class)
+		s.out += fmt.Sprintf("type %s struct {\n\t*jagrt.Exception\n}\n\n", name)
+	}
+	s.out += "func init() {\n"
+	for _, class := range classes {
+		s.out += fmt.Sprintf("\tjagrt.RegisterException(\"%s\", func(e *jagrt.Exception) error { return &%s{e} })\n", class, s.exceptionTypeName(class))
+	}
+	s.out += "}\n"
+}
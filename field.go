@@ -0,0 +1,107 @@
+package jag
+
+import (
+	"fmt"
+)
+
+// fieldSetter is a field for GenerateFuncName, naming the function setting
+// it.
+type fieldSetter struct {
+	*ClassSigField
+}
+
+// GenerateField writes the getter of a field, and a setter unless it is
+// final. Static final fields with a primitive or String constant value are
+// written as Go constants instead. Static fields get functions,
+// LocalFooAnswer and SetLocalFooAnswer for local.Foo.answer, instance fields
+// get methods, Answer and SetAnswer. The names get a Field suffix if
+// funcNames, the names of the methods, has them.
+func (s *StringGenerator) GenerateField(field *ClassSigField, goClassTypeName, goClassType string, funcNames map[string]bool) {
+	sig := s.Gen.GetClassSignature()
+	name := capitalize(field.Name)
+	var getter, setter, receiver string
+	for {
+		if field.Static {
+			getter, setter = goClassTypeName+name, "Set"+goClassTypeName+name
+		} else {
+			getter, setter = name, "Set"+name
+			receiver = "(jbobject *" + goClassType + ") "
+		}
+		if !funcNames[getter] && (field.Final || !funcNames[setter]) {
+			break
+		}
+		name += "Field"
+	}
+
+	// static fields are looked up by class name
+	var classArg string
+	if field.Static {
+		classArg = "\"" + sig.GetClassName() + "\", "
+	}
+
+	ret := s.Gen.JavaToGoTypeName(field.Type)
+	// constants are declared, other static finals are read from Java
+	var literal string
+	var constant bool
+	if field.Static && field.Final && field.Value != "" {
+		literal, constant = goConstant(field.Type, ret, field.Value)
+	}
+	if field.Doc != "" {
+		s.printDoc(field.Doc, "")
+	} else if constant {
+		s.out += fmt.Sprintf("// %s is the value of the Java constant %s.\n", getter, field.Name)
+	} else {
+		s.out += fmt.Sprintf("// %s returns the Java field %s.\n", getter, field.Name)
+	}
+	if constant {
+		s.out += fmt.Sprintf("const %s %s = %s\n\n", getter, ret, literal)
+		return
+	}
+	s.out += "func " + receiver + getter + "() "
+	if s.NoPanic {
+		s.out += "(" + ret + ", error) {\n"
+	} else {
+		s.out += ret + " {\n"
+	}
+	s.out += "\tjret, err := "
+	s.GenerateFuncName(field)
+	s.out += "(" + classArg + "\"" + field.Name + "\""
+	if !s.Gen.IsGoJVMType(field.Type) {
+		jretcomp := JavaTypeComponents(s.Gen.JavaErasure(field.Type))
+		comp := jretcomp[0]
+		if comp == "[]" {
+			comp = jretcomp[1]
+		}
+		s.out += ", \"" + comp + "\""
+	}
+	s.out += ")\n"
+	s.out += "\tif err != nil {\n\t\t" + s.onCallError(ret, false) + "\n\t}\n"
+	s.GenerateReturnConversion(field.Type, s.onConvertError(ret))
+	if s.NoPanic {
+		s.out += ", nil"
+	}
+	s.out += "\n}\n\n"
+
+	if field.Final {
+		return
+	}
+	params := Params{{"v", field.Type}}
+	s.out += fmt.Sprintf("// %s sets the Java field %s.\n", setter, field.Name)
+	s.out += "func " + receiver + setter + "("
+	s.printParams(params)
+	s.out += ") "
+	if s.NoPanic {
+		s.out += "error "
+	}
+	s.out += "{\n"
+	s.GenerateParamConversion(params, s.onConvertError(""))
+	s.out += "\terr := "
+	s.GenerateFuncName(fieldSetter{field})
+	s.out += "(" + classArg + "\"" + field.Name + "\", " + s.GenerateCallArgs(params)[0] + ")\n"
+	s.out += "\tif err != nil {\n\t\t" + s.onCallError("", false) + "\n\t}\n"
+	s.GenerateParamConversionCleanup(params)
+	if s.NoPanic {
+		s.out += "\treturn nil\n"
+	}
+	s.out += "}\n\n"
+}
@@ -7,6 +7,7 @@ import (
 	"go/token"
 	"io"
 	"bufio"
+	"sort"
 )
 
 type Generator interface {
@@ -15,6 +16,9 @@ type Generator interface {
 	TranslatorInterface
 	ImportListInterface
 	IsAbstractClass(name string) bool
+	IsEnum(name string) bool
+	IsRecord(name string) bool
+	IsGeneric(name string) bool
 }
 
 type ImportListInterface interface {
@@ -26,6 +30,10 @@ type TranslatorInterface interface {
 	ConverterForType(prefix, s string) (z string)
 	IsGoJVMType(s string) bool
 	IsCallableType(s string) bool
+	IsEnumType(s string) bool
+	IsRecordType(s string) bool
+	ConversionImports(s string) []string
+	JavaErasure(s string) string
 	javaNameToGoName(s string) (z string)
 }
 
@@ -68,15 +76,82 @@ var typeMap = map[string]string{
 	"int[]":"[]int",
 }
 
+const (
+	goToJavaPrefix = "javabind.NewGoToJava"
+	javaToGoPrefix = "javabind.NewJavaToGo"
+	// runtime support for type variables of generic classes
+	jagrtImport = "github.com/timob/jag/jagrt"
+)
+
 type Translator struct {
 	Gen Generator
 	TypeMap map[string]string
 	ObjectConversions map[string]string
+	// checked before ObjectConversions
+	Registry *ConverterRegistry
+	// optional class name shortening rules
+	Names *NameRules
 	trim string
 }
 
 func NewTranslator(g Generator, trim string) *Translator {
-	return &Translator{g, typeMap, objectConversions, trim}
+	t := &Translator{Gen: g, TypeMap: make(map[string]string), ObjectConversions: make(map[string]string), Registry: NewConverterRegistry(), trim: trim}
+	for k, v := range typeMap {
+		t.TypeMap[k] = v
+	}
+	for k, v := range objectConversions {
+		t.ObjectConversions[k] = v
+	}
+	return t
+}
+
+// AddConversions merges conversions from a config over the current ones.
+func (t *Translator) AddConversions(c *ConversionConfig) {
+	for k, v := range c.Types {
+		t.TypeMap[k] = v
+	}
+	for _, conv := range c.Conversions {
+		t.Registry.Register(conv)
+	}
+}
+
+func (t *Translator) conversion(s string) *Conversion {
+	if c := t.Registry.Lookup(s); c != nil {
+		return c
+	}
+	if v, ok := t.ObjectConversions[s]; ok {
+		return &Conversion{Java: s, Go: v}
+	}
+	return nil
+}
+
+// IsConverted reports whether values of the Java class s are converted to a
+// Go type, rather than bound to a generated one.
+func (t *Translator) IsConverted(s string) bool {
+	return t.conversion(s) != nil
+}
+
+func (t *Translator) ConversionImports(s string) []string {
+	if t.isTypeVariable(s) {
+		return []string{jagrtImport}
+	}
+	if c := t.conversion(s); c != nil {
+		return c.AllImports()
+	}
+	return nil
+}
+
+// isTypeVariable reports whether s is a type parameter of the generic class
+// being generated, which is bound as a Go type parameter of the same name.
+func (t *Translator) isTypeVariable(s string) bool {
+	_, ok := t.Gen.GetClassSignature().GetTypeParams().lookup(s)
+	return ok
+}
+
+// JavaErasure returns the type JNI sees for a Java type, with the type
+// variables of the class being generated erased.
+func (t *Translator) JavaErasure(s string) string {
+	return t.Gen.GetClassSignature().GetTypeParams().Erase(s)
 }
 
 func (t *Translator) JavaToGoTypeName(s string) (z string) {
@@ -85,21 +160,41 @@ func (t *Translator) JavaToGoTypeName(s string) (z string) {
 		defer func() {log.Printf("translated to: " + z) }()
 	}
 
+	jt := parseJavaTypeName(s).WildcardBound()
+	s = jt.String()
 	if v, ok := t.TypeMap[s]; ok {
 		return v
 	}
+	if t.isTypeVariable(s) {
+		return s
+	}
 
-	jc := JavaTypeComponents(s)
-	prefix := jc[0]
-	if v, ok := t.ObjectConversions[prefix]; ok {
+	head, parts := jt.split()
+	if c := t.conversion(head); c != nil {
 		gc := make([]interface{}, 0)
-		for i := 1; i < len(jc); i++ {
-			gc = append(gc, t.Gen.JavaToGoTypeName(jc[i]))
+		for _, part := range parts {
+			gc = append(gc, t.Gen.JavaToGoTypeName(part.String()))
 		}
-		return fmt.Sprintf(v, gc...)
+		return fmt.Sprintf(c.GoType(), gc...)
 	}
 
-	return "*" + t.Gen.javaNameToGoName(prefix)
+	// enums and records are values, other classes pointers to a struct
+	z = t.Gen.javaNameToGoName(head)
+	if t.IsEnumType(head) {
+		return
+	} else if !t.IsRecordType(head) {
+		z = "*" + z
+	}
+	// a generic class, instantiated with its type arguments, which are
+	// erased for a class not generated as a generic type
+	if len(parts) > 0 && t.Gen.IsGeneric(head) {
+		args := make([]string, 0, len(parts))
+		for _, arg := range parts {
+			args = append(args, t.Gen.JavaToGoTypeName(arg.String()))
+		}
+		z += "[" + strings.Join(args, ", ") + "]"
+	}
+	return
 }
 
 func (t *Translator) IsGoJVMType(s string) bool {
@@ -108,15 +203,36 @@ func (t *Translator) IsGoJVMType(s string) bool {
 }
 
 func (t *Translator) IsCallableType(s string) bool {
-	_, ok := t.ObjectConversions[s]
-	return !ok
+	return t.conversion(s) == nil && !t.isTypeVariable(s) && !t.IsEnumType(s) && !t.IsRecordType(s)
+}
+
+// IsEnumType reports whether s is a Java enum, bound as a Go named type with
+// converters generated along with it.
+func (t *Translator) IsEnumType(s string) bool {
+	return t.Gen.IsEnum(s)
+}
+
+// IsRecordType reports whether s is a Java record, bound as a Go struct
+// value with converters generated along with it.
+func (t *Translator) IsRecordType(s string) bool {
+	return t.Gen.IsRecord(s)
 }
 
 func (t *Translator) javaNameToGoName(s string) (z string) {
+	javaName := s
 	s = strings.TrimPrefix(s, t.trim + ".")
-	for _, part := range strings.Split(s, ".") {
+	if t.Names != nil {
+		s = t.Names.rewrite(s)
+	}
+	// nested classes are named after the classes enclosing them,
+	// local.Outer$Inner is LocalOuterInner
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '$' }) {
 		z += capitalize(part)
 	}
+	if t.Names != nil {
+		z = t.Names.stripSuffix(z)
+		t.Names.record(javaName, z)
+	}
 	return
 }
 
@@ -131,7 +247,7 @@ func NewCallableList(t TranslatorInterface) *CallableList {
 
 func (c *CallableList) JavaToGoTypeName(s string) (z string) {
 	jc := JavaTypeComponents(s)
-	if !c.IsGoJVMType(jc[0]) && c.IsCallableType(jc[0]) {
+	if !c.IsGoJVMType(jc[0]) && (c.IsCallableType(jc[0]) || c.IsEnumType(jc[0]) || c.IsRecordType(jc[0])) {
 		c.callables[jc[0]] = 1
 	}
 
@@ -152,11 +268,12 @@ var importMap = map[string]string {
 type ImportList struct {
 	importMap map[string]string
 	convertedTypes map[string]byte
+	imports map[string]byte
 	TranslatorInterface
 }
 
 func NewImportList(t TranslatorInterface) *ImportList {
-	return &ImportList{importMap, make(map[string]byte), t}
+	return &ImportList{importMap, make(map[string]byte), make(map[string]byte), t}
 }
 
 func (c *ImportList) JavaToGoTypeName(s string) (z string) {
@@ -164,67 +281,143 @@ func (c *ImportList) JavaToGoTypeName(s string) (z string) {
 	jc := JavaTypeComponents(s)
 	if !c.IsGoJVMType(jc[0]) && !c.IsCallableType(jc[0]) {
 		c.convertedTypes[name] = 1
+		for _, importName := range c.ConversionImports(jc[0]) {
+			c.imports[importName] = 1
+		}
 	}
 
 	return name
 }
 
 func (c *ImportList) ListImports() (list []string) {
+	imports := make(map[string]byte)
+	for k, _ := range c.imports {
+		imports[k] = 1
+	}
 	for k, _ := range c.convertedTypes {
 		for name, importedName := range c.importMap {
 			if strings.HasPrefix(k, name + ".") {
-				list = append(list, importedName)
+				imports[importedName] = 1
 			}
 		}
 	}
+	for k, _ := range imports {
+		list = append(list, k)
+	}
+	sort.Strings(list)
 	return
 }
 
 // NewGoToJavaList(NewGoToJavaString())
 // NewGoToJavaList(NewGoToJavaList(NewGoToJavaString())
 func (t *Translator) ConverterForType(prefix, s string) (z string) {
-	jc := JavaTypeComponents(s)
+	jt := parseJavaTypeName(s).WildcardBound()
+	s = jt.String()
+	if t.isTypeVariable(s) {
+		// picked at run time from the Go type argument
+		return strings.Replace(prefix, "javabind.", "jagrt.", 1) + "[" + s + "]()"
+	}
+	head, parts := jt.split()
 
 	var name string
-	if jc[0] == "..." || jc[0] == "[]" {
+	if jt.IsArray() {
 		name = "ObjectArray"
-	} else if t.IsCallableType(jc[0]) {
+	} else if t.IsCallableType(head) {
 		return prefix + "Callable()"
+	} else if t.IsEnumType(head) || t.IsRecordType(head) {
+		// generated with the enum or record
+		z = strings.TrimPrefix(prefix, "javabind.") + t.Gen.javaNameToGoName(head)
+		if len(parts) > 0 && t.Gen.IsGeneric(head) {
+			args := make([]string, len(parts))
+			for i, part := range parts {
+				args[i] = t.Gen.JavaToGoTypeName(part.String())
+			}
+			z += "[" + strings.Join(args, ", ") + "]"
+		}
+		return z + "()"
 	} else {
-		name = strings.Replace(className(jc[0]), "$", "_", -1)
+		name = strings.Replace(className(head), "$", "_", -1)
 	}
 	z = prefix + name + "("
+	if c := t.conversion(head); c != nil && c.Converter(prefix) != "" {
+		z = c.Converter(prefix) + "("
+	}
 
-	for i := 1; i < len(jc); i++ {
-		if i != 1 {
+	for i, part := range parts {
+		if i != 0 {
 			z += ", "
 		}
-		z += t.ConverterForType(prefix, jc[i])
+		z += t.ConverterForType(prefix, part.String())
 	}
 	z += ")"
 	return
 }
 
+// AbstractClassList knows which classes are interfaces or abstract classes.
+// Classes are added with their kind as they are parsed, and the list read by
+// NewAbstractClassList overrides that: one class name per line, or a name
+// prefixed with - for a class that should not be treated as abstract. It
+// also knows which classes are generic, those are generated as generic types.
 type AbstractClassList struct {
-	list map[string]byte
+	list map[string]bool
+	kinds map[string]ClassKind
+	generic map[string]bool
 }
 
 func NewAbstractClassList(reader io.Reader) (a *AbstractClassList) {
 	a = new(AbstractClassList)
-	a.list = make(map[string]byte)
+	a.list = make(map[string]bool)
+	a.kinds = make(map[string]ClassKind)
+	a.generic = make(map[string]bool)
 	if reader == nil {
 		return
 	}
 	lineScanner := bufio.NewScanner(reader)
 	for lineScanner.Scan() {
-		a.list[lineScanner.Text()] = 1
+		name := strings.TrimSpace(lineScanner.Text())
+		if name == "" {
+			continue
+		}
+		if strings.HasPrefix(name, "-") {
+			a.list[name[1:]] = false
+		} else {
+			a.list[name] = true
+		}
 	}
 	return
 }
 
+// AddClass records the kind of a parsed class.
+func (a *AbstractClassList) AddClass(name string, kind ClassKind) {
+	a.kinds[name] = kind
+}
+
+// AddGeneric records that a class is generic, so types using it are given
+// its type arguments.
+func (a *AbstractClassList) AddGeneric(name string) {
+	a.generic[name] = true
+}
+
+// IsGeneric reports whether a class added is generic.
+func (a *AbstractClassList) IsGeneric(name string) bool {
+	return a.generic[name]
+}
+
 func (a *AbstractClassList) IsAbstractClass(name string) bool {
-	_, ok := a.list[name]
-	return ok
+	if abstract, ok := a.list[name]; ok {
+		return abstract
+	}
+	return a.kinds[name].IsAbstract()
+}
+
+// IsEnum reports whether a class added is an enum.
+func (a *AbstractClassList) IsEnum(name string) bool {
+	return a.kinds[name] == Enum
+}
+
+// IsRecord reports whether a class added is a record.
+func (a *AbstractClassList) IsRecord(name string) bool {
+	return a.kinds[name] == Record
 }
 
 func javaToGoIdentifier(s string) (z string) {
@@ -238,6 +431,11 @@ type StringGenerator struct {
 	out string
 	Gen Generator
 	PkgName string
+	// names overloaded constructors and methods, IndexNaming if nil
+	Namer OverloadNamer
+	// every function returns an error instead of panicking, for unchecked
+	// exceptions and failed conversions too
+	NoPanic bool
 }
 
 func (s *StringGenerator) printParams(params Params) {
@@ -245,34 +443,131 @@ func (s *StringGenerator) printParams(params Params) {
 		if i != 0 {
 			s.out += ", "
 		}
-		var typeName string
+		typeName := s.Gen.JavaToGoTypeName(p.Type)
 		firstComponent := JavaTypeComponents(p.Type)[0]
 		if s.Gen.IsAbstractClass(firstComponent) {
-			typeName = "interface{}"
-		} else {
-			typeName = s.Gen.JavaToGoTypeName(p.Type)
+			typeName = interfaceTypeName(typeName)
 		}
 		s.out += javaToGoIdentifier(p.Name) + " " + typeName
 	}
 }
 
-func (s *StringGenerator) GenerateParamConversion(p Params) {
+// interfaceTypeName returns the name of the Go interface generated for an
+// interface or abstract class, from the name of its struct type.
+func interfaceTypeName(goType string) string {
+	goType = strings.TrimPrefix(goType, "*")
+	if i := strings.Index(goType, "["); i >= 0 {
+		return goType[:i] + "Interface" + goType[i:]
+	}
+	return goType + "Interface"
+}
+
+// returnsError reports whether the function for a constructor, method or
+// field returns an error, throws is whether it is declared to throw.
+func (s *StringGenerator) returnsError(throws bool) bool {
+	return throws || s.NoPanic
+}
+
+// returnError returns the statement returning err from a function whose
+// other result has the Go type ret, if any.
+func returnError(ret, err string) string {
+	if ret == "" {
+		return "return " + err
+	} else if strings.HasPrefix(ret, "*") {
+		return "return nil, " + err
+	}
+	return "var zero " + ret + "\n\t\treturn zero, " + err
+}
+
+// onCallError returns the statement run when the JNI call of a function
+// returning ret fails.
+func (s *StringGenerator) onCallError(ret string, throws bool) string {
+	if s.returnsError(throws) {
+		return returnError(ret, "jagrt.NewException(err)")
+	}
+	return "panic(err)"
+}
+
+// onConvertError returns the statement run when a conversion in a function
+// returning ret fails.
+func (s *StringGenerator) onConvertError(ret string) string {
+	if s.NoPanic {
+		return returnError(ret, "err")
+	}
+	return "panic(err)"
+}
+
+// printResults writes the results of a method, returning the Go type of the
+// Java return value.
+func (s *StringGenerator) printResults(method *ClassSigMethod) (ret string) {
+	ret = s.Gen.JavaToGoTypeName(method.Return)
+	if ret != "" {
+		if s.returnsError(method.Throws) {
+			s.out += "(" + ret  + ", error)"
+		} else {
+			s.out += ret
+		}
+	} else {
+		if s.returnsError(method.Throws) {
+			s.out += "error"
+		}
+	}
+	return
+}
+
+// GenerateInterface writes a Go interface with the instance methods of an
+// interface or abstract class. It is satisfied by the struct generated for
+// the class and those of its subclasses, and used for parameters of the type.
+// It embeds the Go interface of extends, the supertype whose struct the
+// struct of the class embeds, when jagen knows it.
+func (s *StringGenerator) GenerateInterface(goClassTypeName, goTypeParams, extends string, methodNames []string) {
+	sig := s.Gen.GetClassSignature()
+	s.out += fmt.Sprintf("// %sInterface is implemented by the Go types for %s and its\n// subclasses.\n", goClassTypeName, sig.GetClassName())
+	s.out += fmt.Sprintf("type %sInterface%s interface {\n", goClassTypeName, goTypeParams)
+	if extends != "" && s.Gen.IsAbstractClass(JavaTypeComponents(extends)[0]) {
+		s.out += "\t" + interfaceTypeName(s.Gen.JavaToGoTypeName(extends)) + "\n"
+	}
+	for i, method := range sig.GetMethods() {
+		if method.Static {
+			continue
+		}
+		s.out += "\t" + methodNames[i] + "("
+		s.printParams(method.Params)
+		s.out += ") "
+		s.printResults(method)
+		s.out = strings.TrimRight(s.out, " ") + "\n"
+	}
+	s.out += "}\n\n"
+}
+
+// GenerateParamConversion writes the conversions of params to Java, onError
+// is run if one fails. With NoPanic, where onError and call errors return,
+// the clean up of each conversion is deferred so no return skips it.
+func (s *StringGenerator) GenerateParamConversion(p Params, onError string) {
 	conversions := make([]string, 0)
 	for _, param := range p {
 		if s.Gen.IsGoJVMType(param.Type) {
 			continue
 		}
-		s.out += "\tconv_" + param.Name + " := " + s.Gen.ConverterForType("javabind.NewGoToJava", param.Type) + "\n"
+		s.out += "\tconv_" + param.Name + " := " + s.Gen.ConverterForType(goToJavaPrefix, param.Type) + "\n"
 		conversions = append(conversions, param.Name)
 	}
 
 	for _, param := range conversions {
-		s.out += "\tif err := conv_" + param + ".Convert(" + javaToGoIdentifier(param) + "); err != nil {\n\t\tpanic(err)\n\t}\n"
+		s.out += "\tif err := conv_" + param + ".Convert(" + javaToGoIdentifier(param) + "); err != nil {\n\t\t" + onError + "\n\t}\n"
+		if s.NoPanic {
+			s.out += "\tdefer conv_" + param + ".CleanUp()\n"
+		}
 	}
 	return
 }
 
+// GenerateParamConversionCleanup writes the clean up of the conversions of
+// params, which is deferred with NoPanic.
 func (s *StringGenerator) GenerateParamConversionCleanup(p Params) {
+	if s.NoPanic {
+		return
+	}
 	for _, param := range p {
 		if s.Gen.IsGoJVMType(param.Type) {
 			continue
@@ -281,11 +576,19 @@ func (s *StringGenerator) GenerateParamConversionCleanup(p Params) {
 	}
 }
 
-func (s *StringGenerator) GenerateReturnConversion(jtype string) {
+// GenerateReturnConversion writes the conversion of jret to Go and returns
+// it, onError is run if it fails.
+func (s *StringGenerator) GenerateReturnConversion(jtype, onError string) {
+	s.GenerateResultConversion(jtype, onError, "return ")
+}
+
+// GenerateResultConversion converts jret to the Go type for jtype, the last
+// statement is result followed by the Go value.
+func (s *StringGenerator) GenerateResultConversion(jtype, onError, result string) {
 	if s.Gen.IsGoJVMType(jtype) {
-		s.out += "\treturn jret"
+		s.out += "\t" + result + "jret"
 	} else {
-		s.out += "\tretconv := " + s.Gen.ConverterForType("javabind.NewJavaToGo", jtype) + "\n"
+		s.out += "\tretconv := " + s.Gen.ConverterForType(javaToGoPrefix, jtype) + "\n"
 		jretcomp := JavaTypeComponents(jtype)
 		firstRetComponent := jretcomp[0]
 		if s.Gen.IsCallableType(firstRetComponent) {
@@ -293,12 +596,12 @@ func (s *StringGenerator) GenerateReturnConversion(jtype string) {
 		} else {
 			s.out += "\tdst := new("+s.Gen.JavaToGoTypeName(jtype)+")\n"
 		}
-		s.out += "\tretconv.Dest(dst)\n\tif err := retconv.Convert(jret); err != nil {\n\t\tpanic(err)\n\t}\n"
+		s.out += "\tretconv.Dest(dst)\n\tif err := retconv.Convert(jret); err != nil {\n\t\t" + onError + "\n\t}\n"
 		s.out += "\tretconv.CleanUp()\n"
 		if s.Gen.IsCallableType(firstRetComponent) {
-            s.out += "\tx := &" +  s.Gen.javaNameToGoName(firstRetComponent) + "{}\n\tx.Callable = dst\n\treturn x"
+            s.out += "\tx := &" +  strings.TrimPrefix(s.Gen.JavaToGoTypeName(jtype), "*") + "{}\n\tx.Callable = dst\n\t" + result + "x"
 		} else {
-			s.out += "\treturn *dst"
+			s.out += "\t" + result + "*dst"
 		}
 	}
 }
@@ -306,16 +609,17 @@ func (s *StringGenerator) GenerateReturnConversion(jtype string) {
 func (s *StringGenerator) GenerateCallArgs(p Params) (args []string) {
 	args = make([]string, len(p))
 	for i, param := range p {
+		erased := s.Gen.JavaErasure(param.Type)
  		if s.Gen.IsGoJVMType(param.Type) {
 			args[i] = param.Name
-		} else if strings.HasSuffix(param.Type, "...") {
-			name := strings.TrimSuffix(param.Type, "...")
+		} else if strings.HasSuffix(erased, "...") {
+			name := strings.TrimSuffix(erased, "...")
 			args[i] = "javabind.ObjectArray(conv_"+param.Name+".Value(), \""+JavaTypeComponents(name)[0]+"\")"
-		} else if strings.HasSuffix(param.Type, "[]") {
-			name := strings.TrimSuffix(param.Type, "[]")
+		} else if strings.HasSuffix(erased, "[]") {
+			name := strings.TrimSuffix(erased, "[]")
 			args[i] = "javabind.ObjectArray(conv_" + param.Name + ".Value(), \"" + JavaTypeComponents(name)[0] + "\")"
 		} else {
-			args[i] = "javabind.CastObject(conv_" + param.Name + ".Value(), \"" + JavaTypeComponents(param.Type)[0] + "\")"
+			args[i] = "javabind.CastObject(conv_" + param.Name + ".Value(), \"" + JavaTypeComponents(erased)[0] + "\")"
 		}
 	}
 	return
@@ -334,6 +638,10 @@ func (s *StringGenerator) GenerateFuncName(t interface{}) {
 		static = v.Static
 		prefix = "GetField"
 		Type = v.Type
+	case fieldSetter:
+		static = v.Static
+		prefix = "SetField"
+		Type = v.Type
 	}
 	if static {
 		s.out += "javabind"
@@ -349,12 +657,33 @@ func (s *StringGenerator) GenerateFuncName(t interface{}) {
 		s.out += capitalize(strings.Replace(Type, "[]", "Array", -1))
 	} else {
 		s.out += "Obj"
-		if JavaTypeComponents(Type)[0] == "[]" {
+		// arrays are set with ObjectArray values
+		if JavaTypeComponents(Type)[0] == "[]" && prefix != "SetField" {
 			s.out += "Array"
 		}
 	}
 }
 
+// printDoc writes a comment with doc followed by the Java declaration line,
+// either can be empty.
+func (s *StringGenerator) printDoc(doc, line string) {
+	if doc != "" {
+		for _, l := range strings.Split(doc, "\n") {
+			if l == "" {
+				s.out += "//\n"
+			} else {
+				s.out += "// " + l + "\n"
+			}
+		}
+		if line != "" {
+			s.out += "//\n"
+		}
+	}
+	if line != "" {
+		s.out += "// " + line + "\n"
+	}
+}
+
 func (s *StringGenerator) Generate() {
 	sig := s.Gen.GetClassSignature()
 	if sig.GetClassName() == "" {
@@ -369,87 +698,112 @@ func (s *StringGenerator) Generate() {
 	*/
 
 	goClassTypeName := s.Gen.javaNameToGoName(JavaTypeComponents(sig.GetClassName())[0])
+	// a generic class is a generic type, goClassType is the type instantiated
+	// with its own parameters for use in receivers and constructors
+	goClassType, goTypeParams, goTypeArgs := goClassTypeName, "", ""
+	if typeParams := sig.GetTypeParams(); len(typeParams) > 0 {
+		names := make([]string, len(typeParams))
+		for i, tp := range typeParams {
+			names[i] = tp.Name
+		}
+		goTypeArgs = "[" + strings.Join(names, ", ") + "]"
+		goClassType += goTypeArgs
+		goTypeParams = "[" + strings.Join(names, ", ") + " any]"
+	}
+
+	// enums and records extend java.lang.Enum and java.lang.Record, which
+	// are not bound
+	extends := sig.GetExtends()
+	if sig.GetKind() == Enum || sig.GetKind() == Record {
+		extends = ""
+	}
+
+	// the struct of the superclass is embedded, unless it is converted to
+	// a Go type like a slice rather than bound
+	field := "*javabind.Callable"
+	if extends != "" {
+		if s.Gen.IsCallableType(JavaTypeComponents(extends)[0]) {
+			field = strings.TrimPrefix(s.Gen.JavaToGoTypeName(extends), "*")
+		} else {
+			extends = ""
+		}
+	}
+
+	namer := s.Namer
+	if namer == nil {
+		namer = IndexNaming{}
+	}
 
-    field := ""
-    if sig.GetExtends() != "" {
-        field = s.Gen.JavaToGoTypeName(sig.GetExtends())
-        //hack to get rid of *
-        field  = field[1:]
-    } else {
-        field = "*javabind.Callable"
+	goStructName := goClassTypeName
+	if sig.GetKind() == Enum || sig.GetKind() == Record {
+		goStructName += "Object"
+		goClassType = goStructName + goTypeArgs
+	}
+	constructorNames := namer.ConstructorNames(sig.GetClassName(), goStructName, sig.GetConstructors())
+	if sig.GetKind() == Enum {
+		// the enum is a named type, the struct holds its Java constants
+		s.GenerateEnum(goClassTypeName, goStructName)
+		s.out += fmt.Sprintf("// %s is a constant of %s as a Java object.\n", goStructName, sig.GetClassName())
+	} else if sig.GetKind() == Record {
+		// the record is a struct value, the struct holds a Java record
+		s.GenerateRecord(goClassTypeName, goStructName, goTypeParams, goTypeArgs, constructorNames)
+		s.out += fmt.Sprintf("// %s is a %s as a Java object.\n", goStructName, sig.GetClassName())
+	} else if sig.GetDoc() != "" {
+        s.printDoc(sig.GetDoc(), "")
     }
+    s.out += fmt.Sprintf("type %s%s struct {\n\t%s\n}\n\n", goStructName, goTypeParams, field)
 
-    s.out += fmt.Sprintf("type %s struct {\n\t%s\n}\n\n", goClassTypeName, field)
+	methodNames := namer.MethodNames(sig.GetClassName(), sig.GetMethods())
+	// the proxy of an interface implements its Go interface
+	if s.Gen.IsAbstractClass(sig.GetClassName()) || sig.GetKind() == Interface {
+		s.GenerateInterface(goClassTypeName, goTypeParams, extends, methodNames)
+	}
+	if sig.GetKind() == Interface {
+		s.GenerateProxy(goClassTypeName, goClassType, goTypeParams, goTypeArgs, extends, methodNames)
+	}
 
 	for i, constructor := range sig.GetConstructors() {
-		s.out += "// "+constructor.Line+"\n"
-		s.out += "func New"+goClassTypeName
-		if i > 0 {
-			s.out += fmt.Sprintf("%d", i+1)
-		}
+		s.printDoc(constructor.Doc, constructor.Line)
+		s.out += "func "+constructorNames[i]+goTypeParams
 		s.out += "("
 		s.printParams(constructor.Params)
 		s.out += ")"
-		s.out += fmt.Sprintf(" (*%s", goClassTypeName)
-		if constructor.Throws {
+		s.out += fmt.Sprintf(" (*%s", goClassType)
+		returnsError := s.returnsError(constructor.Throws)
+		if returnsError {
 			s.out += ", error"
 		}
 		s.out += ") {\n"
-		s.GenerateParamConversion(constructor.Params)
+		s.GenerateParamConversion(constructor.Params, s.onConvertError("*"+goClassType))
 		newInstanceArgs := make([]string, 0)
 		newInstanceArgs = append(newInstanceArgs, `"`+sig.GetClassName()+`"`)
 		newInstanceArgs = append(newInstanceArgs, s.GenerateCallArgs(constructor.Params)...)
-		var onError string
-		if constructor.Throws {
-			onError = "return nil, err"
-		} else {
-			onError = "panic(err)"
-		}
 		s.out += `
 	obj, err := javabind.Env.NewInstanceStr(`+strings.Join(newInstanceArgs, ", ")+`)
 	if err != nil {
-		`+onError+`
+		`+s.onCallError("*"+goClassType, constructor.Throws)+`
 	}` + "\n"
 		s.GenerateParamConversionCleanup(constructor.Params)
-        s.out += "\tx := &"+goClassTypeName+"{}\n\tx.Callable = &javabind.Callable{obj, javabind.Env}\n\treturn x"
-		if constructor.Throws {
+        s.out += "\tx := &"+goClassType+"{}\n\tx.Callable = &javabind.Callable{obj, javabind.Env}\n\treturn x"
+		if returnsError {
 			s.out += ", nil"
 		}
 		s.out += "\n}\n\n"
 	}
 
-	methodCount := make(map[string]int)
-	for _, method := range sig.GetMethods() {
-		s.out += "// " + method.Line + "\n"
+	for i, method := range sig.GetMethods() {
+		s.printDoc(method.Doc, method.Line)
 		if method.Static {
-			s.out += fmt.Sprintf("func %s", goClassTypeName + capitalize(method.Name))
-		} else {
-			s.out += fmt.Sprintf("func (jbobject *%s) %s", goClassTypeName, capitalize(method.Name))
-		}
-		if v , ok := methodCount[method.Name]; ok {
-			v++
-			s.out += fmt.Sprintf("%d", v)
-			methodCount[method.Name] = v
+			s.out += fmt.Sprintf("func %s", goClassTypeName + methodNames[i])
 		} else {
-			methodCount[method.Name] = 1
+			s.out += fmt.Sprintf("func (jbobject *%s) %s", goClassType, methodNames[i])
 		}
 		s.out += "("
 		s.printParams(method.Params)
 		s.out += ") "
-		ret := s.Gen.JavaToGoTypeName(method.Return)
-		if ret != "" {
-			if method.Throws {
-				s.out += "(" + ret  + ", error)"
-			} else {
-				s.out += ret
-			}
-		} else {
-			if method.Throws {
-				s.out += "error"
-			}
-		}
+		ret := s.printResults(method)
 		s.out += " {\n"
-		s.GenerateParamConversion(method.Params)
+		s.GenerateParamConversion(method.Params, s.onConvertError(ret))
 		s.out += "\t"
 		if ret != "" {
 			s.out += "jret, "
@@ -461,7 +815,7 @@ func (s *StringGenerator) Generate() {
 			callArgs = append(callArgs, `"` + sig.GetClassName() + `"`)
 		}
 		callArgs = append(callArgs, `"` + method.Name + `"`)
-		jretcomp := JavaTypeComponents(method.Return)
+		jretcomp := JavaTypeComponents(s.Gen.JavaErasure(method.Return))
 		if !s.Gen.IsGoJVMType(method.Return) {
 			comp := jretcomp[0]
 			if comp == "[]" {
@@ -471,64 +825,59 @@ func (s *StringGenerator) Generate() {
 		}
 		callArgs = append(callArgs, s.GenerateCallArgs(method.Params)...)
 		s.out += "(" + strings.Join(callArgs, ", ") + ")\n"
-		s.out += "\tif err != nil {\n\t\t"
-		if method.Throws {
-			if ret != "" {
-				s.out += "var zero "+ret+"\n"
-				s.out += "\t\treturn zero, "
-			} else {
-				s.out += "return "
-			}
-			s.out += "err\n"
-		} else {
-			s.out += "panic(err)\n"
-		}
-		s.out += "\t}\n"
+		s.out += "\tif err != nil {\n\t\t" + s.onCallError(ret, method.Throws) + "\n\t}\n"
 		s.GenerateParamConversionCleanup(method.Params)
 		if ret != "" {
-			s.GenerateReturnConversion(method.Return)
-			if method.Throws {
+			s.GenerateReturnConversion(method.Return, s.onConvertError(ret))
+			if s.returnsError(method.Throws) {
 				s.out += ", nil"
 			}
-		} else if method.Throws {
+		} else if s.returnsError(method.Throws) {
 			s.out += "\treturn nil"
 		}
 		s.out += "\n}\n\n"
 	}
 
-	for _ , field := range sig.GetFields() {
-		if field.Static == false {
-			continue
+	// field accessors are renamed if a method has their name
+	funcNames := make(map[string]bool)
+	for i, method := range sig.GetMethods() {
+		if method.Static {
+			funcNames[goClassTypeName + methodNames[i]] = true
+		} else {
+			funcNames[methodNames[i]] = true
 		}
-		ret := s.Gen.JavaToGoTypeName(field.Type)
-		s.out += "func " + goClassTypeName + capitalize(field.Name) + "() " +ret+ " {\n"
-		s.out += "\tjret, err := "
-		s.GenerateFuncName(field)
-		s.out += "(\"" + sig.GetClassName() + "\", \"" + field.Name + "\""
-		if !s.Gen.IsGoJVMType(field.Type) {
-			jretcomp := JavaTypeComponents(field.Type)
-			comp := jretcomp[0]
-			if comp == "[]" {
-				comp = jretcomp[1]
-			}
-			s.out += ", \"" + comp + "\""
+	}
+	s.GenerateUpcasts(goClassType, extends, funcNames)
+	s.GenerateCasts(goClassTypeName, goStructName, goClassType, goTypeParams)
+	for _, field := range sig.GetFields() {
+		if sig.GetKind() == Enum && field.Enum {
+			continue
 		}
-		s.out += ")\n"
-		s.out += "\tif err != nil {\n\t\tpanic(err)\n\t}\n"
-		s.GenerateReturnConversion(field.Type)
-		s.out += "\n}\n\n"
+		s.GenerateField(field, goClassTypeName, goClassType, funcNames)
 	}
 
+	// every class has casts, which use javabind and jagrt
 	prefix := "package " + s.PkgName + "\n\n"
-    if sig.GetExtends() == "" || len(sig.GetConstructors()) != 0 {
-        prefix += "import \"github.com/timob/javabind\"\n"
-    }
-	for _, importName := range s.Gen.ListImports() {
+	prefix += "import \"github.com/timob/javabind\"\n"
+	imports := addImport(s.Gen.ListImports(), jagrtImport)
+	if sig.GetKind() == Enum {
+		imports = addImport(imports, "strconv")
+	}
+	for _, importName := range imports {
 		prefix += "import \"" + importName + "\"\n"
 	}
 	s.out = prefix + "\n" + s.out
 }
 
+// addImport adds name to the sorted imports if it is not there.
+func addImport(imports []string, name string) []string {
+	i := sort.SearchStrings(imports, name)
+	if i < len(imports) && imports[i] == name {
+		return imports
+	}
+	return append(imports[:i], append([]string{name}, imports[i:]...)...)
+}
+
 func (s *StringGenerator) Output() string {
 	return s.out
 }
@@ -0,0 +1,664 @@
+package jag
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// generateJavap runs javap output through the parser and generator the same
+// way cmd/jagen does, abstractClasses is the -abstract file.
+func generateJavap(javap string, setup func(t *Translator), abstractClasses string) string {
+	return generateJavapWith(javap, setup, NewAbstractClassList(strings.NewReader(abstractClasses)), &StringGenerator{PkgName: "test"})
+}
+
+// generateJavapWith is generateJavap with the kinds of other classes already
+// added to classes, and the StringGenerator options in s.
+func generateJavapWith(javap string, setup func(t *Translator), classes *AbstractClassList, s *StringGenerator) string {
+	handle := &ParserHandle{}
+	sig := &ClassSig{Parser: handle}
+	parser := NewParser(handle, NewStatements(handle), &Tokens{Parser: handle}, sig, &JavapParams{Parser: handle}, strings.NewReader(javap))
+	if err := parser.Scan(); err != nil {
+		panic(err)
+	}
+
+	classes.AddClass(sig.ClassName, sig.Kind)
+	if len(sig.TypeParams) > 0 {
+		classes.AddGeneric(sig.ClassName)
+	}
+
+	genHandle := &GeneratorHandle{}
+	translator := NewTranslator(genHandle, "")
+	if setup != nil {
+		setup(translator)
+	}
+	importList := NewImportList(translator)
+	filter := NewClassSigFilter(handle.Parser, "")
+	handle.Parser = filter
+
+	gen := &struct {
+		TranslatorInterface
+		ImportListInterface
+		*ClassSigFilter
+		*StringGenerator
+		*AbstractClassList
+	}{
+		importList,
+		importList,
+		filter,
+		s,
+		classes,
+	}
+	s.Gen = genHandle
+	genHandle.Generator = gen
+	gen.Generate()
+	return gen.Output()
+}
+
+func TestConversionConfig(t *testing.T) {
+	config, err := LoadConversions(strings.NewReader(`{
+		"types": {"short": "int16"},
+		"conversions": [
+			{"java": "java.util.UUID", "go": "uuid.UUID", "goToJava": "uuidconv.NewGoToJava", "javaToGo": "uuidconv.NewJavaToGo",
+			 "imports": ["github.com/google/uuid", "example.com/uuidconv"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := generateJavap(`public class local.Foo {
+  public java.util.UUID id(java.util.UUID, short);
+}
+`, func(t *Translator) { t.AddConversions(config) }, "")
+
+	for _, want := range []string{
+		"func (jbobject *LocalFoo) Id(a uuid.UUID, b int16) uuid.UUID {",
+		"conv_a := uuidconv.NewGoToJava()",
+		"retconv := uuidconv.NewJavaToGo()",
+		`import "example.com/uuidconv"`,
+		`import "github.com/google/uuid"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestLoadConversionsErrors(t *testing.T) {
+	for config, want := range map[string]string{
+		`{"conversions": [{"java": "java.util.UUID", "go": "uuid.UUID"}, {"java": "java.net.URI"}]}`: "conversions[1]: empty Java or Go type",
+		`{"conversions": [{"go": "uuid.UUID"}]}`:                                                     "conversions[0]: empty Java or Go type",
+		`{"conversions": [null]}`:                                                                    "conversions[0]: empty Java or Go type",
+		`{"conversions": [{"java": "java.util.[", "go": "x.Y"}]}`:                                    "conversions[0]: java \"java.util.[\": syntax error in pattern",
+		`{"types": {"short": ""}}`:                                                                   "types: \"short\": empty Java or Go type",
+	} {
+		if _, err := LoadConversions(strings.NewReader(config)); err == nil || err.Error() != want {
+			t.Errorf("%s: got %v, want %s", config, err, want)
+		}
+	}
+}
+
+func TestConversionPattern(t *testing.T) {
+	c := &Conversion{Java: "java.util.*", Go: "[]%s"}
+	for javaType, want := range map[string]bool{
+		"java.util.List":              true,
+		"java.util.concurrent.Future": true,
+		"java.utility":                false,
+		"java.lang.String":            false,
+	} {
+		if got := c.matches(javaType); got != want {
+			t.Errorf("%s: got %v, want %v", javaType, got, want)
+		}
+	}
+	if c := (&Conversion{Java: "java.time.Duration"}); !c.matches("java.time.Duration") || c.matches("java.time.Instant") {
+		t.Error("exact name")
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for name, want := range map[string]string{
+		"github.com/google/uuid.UUID":  "uuid.UUID",
+		"gopkg.in/yaml.v2.Node":        "yaml.Node",
+		"github.com/x/foo.bar.Type":    "foo.Type",
+		"github.com/x/go-foo/v3.Type":  "foo.Type",
+		"github.com/x/foo-bar.go.Type": "foo_bar.Type",
+	} {
+		got, imports := unqualify(name)
+		if got != want || len(imports) != 1 || imports[0] != name[:strings.LastIndex(name, ".")] {
+			t.Errorf("%s: got %s %v, want %s", name, got, imports, want)
+		}
+	}
+}
+
+func TestConverterRegistry(t *testing.T) {
+	out := generateJavap(`public class local.Foo {
+  public static java.time.Duration timeout(java.util.List<java.time.Duration>);
+}
+`, func(t *Translator) {
+		t.Registry.Register(&Conversion{
+			Java:     "java.time.Duration",
+			Go:       "time.Duration",
+			GoToJava: "github.com/example/jconv/v2.NewGoToJavaDuration",
+			JavaToGo: "github.com/example/jconv/v2.NewJavaToGoDuration",
+			Imports:  []string{"time"},
+		})
+	}, "")
+
+	for _, want := range []string{
+		"func LocalFooTimeout(a []time.Duration) time.Duration {",
+		"conv_a := javabind.NewGoToJavaList(jconv.NewGoToJavaDuration())",
+		"retconv := jconv.NewJavaToGoDuration()",
+		`import "github.com/example/jconv/v2"`,
+		`import "time"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenericClass(t *testing.T) {
+	classes := NewAbstractClassList(nil)
+	classes.AddGeneric("local.Base")
+	out := generateJavapWith(`public class local.Box<T extends java.lang.Number> extends local.Base<T> {
+  public local.Box(T);
+  public T get();
+  public void set(java.util.List<T>);
+  public local.Box<java.lang.String> strings(java.lang.Class<?>);
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+
+	for _, want := range []string{
+		"type LocalBox[T any] struct {\n\tLocalBase[T]\n}",
+		"func NewLocalBox[T any](a T) (*LocalBox[T]) {",
+		"conv_a := jagrt.NewGoToJava[T]()",
+		`javabind.Env.NewInstanceStr("local.Box", javabind.CastObject(conv_a.Value(), "java.lang.Number"))`,
+		"x := &LocalBox[T]{}",
+		"func (jbobject *LocalBox[T]) Get() T {",
+		`jbobject.CallObj("get", "java.lang.Number")`,
+		"retconv := jagrt.NewJavaToGo[T]()",
+		"conv_a := javabind.NewGoToJavaList(jagrt.NewGoToJava[T]())",
+		// java.lang.Class is not known to be generated generic
+		"func (jbobject *LocalBox[T]) Strings(a *JavaLangClass) *LocalBox[string] {",
+		"x := &LocalBox[string]{}",
+		`import "github.com/timob/jag/jagrt"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestAbstractClassInterface(t *testing.T) {
+	abstract := "local.Shape\n"
+	out := generateJavap(`public abstract class local.Shape {
+  public local.Shape();
+  public abstract double area();
+  public void scale(double) throws java.io.IOException;
+  public static local.Shape unit();
+  public boolean bigger(local.Shape);
+}
+`, nil, abstract)
+
+	for _, want := range []string{
+		"type LocalShapeInterface interface {\n\tArea() float64\n\tScale(a float64) error\n\tBigger(a LocalShapeInterface) bool\n}",
+		"func (jbobject *LocalShape) Bigger(a LocalShapeInterface) bool {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	out = generateJavap(`public class local.Canvas {
+  public void draw(local.Shape);
+}
+`, nil, abstract)
+	if want := "func (jbobject *LocalCanvas) Draw(a LocalShapeInterface)  {"; !strings.Contains(out, want) {
+		t.Fatalf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestInterfaceProxy(t *testing.T) {
+	out := generateJavap(`public interface local.Listener {
+  public abstract void changed(java.lang.String, int) throws java.io.IOException;
+  public abstract java.util.List<java.lang.String> names();
+  public default boolean enabled();
+}
+`, nil, "")
+
+	for _, want := range []string{
+		"import \"github.com/timob/jag/jagrt\"\n",
+		"type LocalListenerInterface interface {\n\tChanged(a string, b int) error\n",
+		"func NewLocalListenerProxy(impl LocalListenerInterface) (proxy *LocalListener, release func()) {",
+		"obj, release, err := jagrt.NewProxy(\"local.Listener\", func(inv *jagrt.Invocation) error {",
+		"method, err := inv.Method()\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\treturn DispatchLocalListener(impl, inv, method)",
+		"func DispatchLocalListener(impl LocalListenerInterface, inv *jagrt.Invocation, method string) error {\n\tswitch method {",
+		"case \"changed(java.lang.String,int)\":",
+		"arg_b, err := inv.CallInt(\"intArg\", 1)",
+		"if err := impl.Changed(arg_a, arg_b); err != nil {",
+		"case \"names()\":",
+		"inv.CallVoid(\"complete\", javabind.CastObject(conv_ret.Value(), \"java.lang.Object\"))",
+		"ret := impl.Enabled()\n\t\tif err := inv.CallVoid(\"completeBoolean\", ret)",
+		"return jagrt.ErrNoMethod",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestInheritedProxy(t *testing.T) {
+	abstract := "local.A\nlocal.B\nlocal.C\n"
+	classes := NewAbstractClassList(strings.NewReader(abstract))
+	classes.AddGeneric("local.B")
+	out := generateJavapWith(`public interface local.A extends local.B<java.lang.String>, local.C, java.lang.Runnable {
+  public abstract void a();
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"type LocalAInterface interface {\n\tLocalBInterface[string]\n\tA()\n}",
+		"func DispatchLocalA(impl LocalAInterface, inv *jagrt.Invocation, method string) error {\n\tswitch method {\n\tcase \"a()\":",
+		// the embedded interface is always there, the others are checked
+		"\tif err := DispatchLocalB[string](impl, inv, method); err != jagrt.ErrNoMethod {\n\t\treturn err\n\t}\n",
+		"\tif impl, ok := impl.(LocalCInterface); ok {\n\t\tif err := DispatchLocalC(impl, inv, method); err != jagrt.ErrNoMethod {\n\t\t\treturn err\n\t\t}\n\t}\n\treturn jagrt.ErrNoMethod\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Runnable") {
+		t.Fatalf("dispatch to unknown interface in:\n%s", out)
+	}
+
+	out = generateJavap(`public interface local.B<T> {
+  public abstract void b(T);
+}
+`, nil, abstract)
+	if want := "func DispatchLocalB[T any](impl LocalBInterface[T], inv *jagrt.Invocation, method string) error {"; !strings.Contains(out, want) {
+		t.Fatalf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	out := generateJavap(`public class local.Files<E extends java.lang.Exception> {
+  public local.Files() throws java.io.IOException;
+  public java.lang.String read(java.lang.String) throws java.io.FileNotFoundException, java.io.IOException;
+  public void run() throws E;
+}
+`, nil, "")
+	for _, want := range []string{
+		"import \"github.com/timob/jag/jagrt\"\n",
+		"return nil, jagrt.NewException(err)",
+		"return zero, jagrt.NewException(err)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	sig, err := ParseClass(strings.NewReader(`public class local.Files<E extends java.lang.Exception> {
+  public java.lang.String read(java.lang.String) throws java.io.FileNotFoundException, java.io.IOException;
+  public void run() throws E;
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := sig.Methods[0].Exceptions; len(e) != 2 || e[0] != "java.io.FileNotFoundException" || e[1] != "java.io.IOException" {
+		t.Fatal(e)
+	}
+	classes := ThrownClasses(sig)
+	if len(classes) != 3 || classes[0] != "java.io.FileNotFoundException" || classes[2] != "java.lang.Exception" {
+		t.Fatal(classes)
+	}
+
+	genHandle := &GeneratorHandle{}
+	gen := &StringGenerator{Gen: genHandle, PkgName: "test"}
+	translator := NewTranslator(genHandle, "")
+	genHandle.Generator = &struct {
+		TranslatorInterface
+		ImportListInterface
+		*ClassSigFilter
+		*StringGenerator
+		*AbstractClassList
+	}{translator, NewImportList(translator), nil, gen, nil}
+	gen.GenerateExceptions(classes)
+	out = gen.Output()
+	for _, want := range []string{
+		"type JavaIoIOExceptionError struct {\n\t*jagrt.Exception\n}",
+		"\tjagrt.RegisterException(\"java.lang.Exception\", func(e *jagrt.Exception) error { return &JavaLangExceptionError{e} })\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if read, err := ReadExceptionClasses(strings.NewReader(out)); err != nil || strings.Join(read, " ") != strings.Join(classes, " ") {
+		t.Fatal(read, err)
+	}
+}
+
+func TestNoPanic(t *testing.T) {
+	javap := `public class local.Foo {
+  public local.Foo(java.lang.String);
+  public int count();
+  public java.lang.String name() throws java.io.IOException;
+  public static final java.lang.String NAME;
+}
+`
+	out := generateJavapWith(javap, nil, NewAbstractClassList(nil), &StringGenerator{PkgName: "test", NoPanic: true})
+	if strings.Contains(out, "panic(") {
+		t.Fatalf("panic in:\n%s", out)
+	}
+	if strings.Contains(out, "\tconv_a.CleanUp()") {
+		t.Fatalf("clean up skipped by error returns in:\n%s", out)
+	}
+	for _, want := range []string{
+		"import \"github.com/timob/jag/jagrt\"\n",
+		"func NewLocalFoo(a string) (*LocalFoo, error) {",
+		"if err := conv_a.Convert(a); err != nil {\n\t\treturn nil, err\n\t}\n\tdefer conv_a.CleanUp()\n",
+		"func (jbobject *LocalFoo) Count() (int, error) {",
+		"if err != nil {\n\t\tvar zero int\n\t\treturn zero, jagrt.NewException(err)\n\t}\n\treturn jret, nil\n}",
+		"func LocalFooNAME() (string, error) {",
+		"func IsInstanceLocalFoo(obj *javabind.Callable) (bool, error) {\n\treturn jagrt.IsInstance(obj, \"local.Foo\")\n}",
+		"func CastToLocalFoo(obj *javabind.Callable) (*LocalFoo, bool, error) {\n\tif ok, err := jagrt.IsInstance(obj, \"local.Foo\"); err != nil || !ok {\n\t\treturn nil, false, err\n\t}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	out = generateJavap(javap, nil, "")
+	for _, want := range []string{
+		"func NewLocalFoo(a string) (*LocalFoo) {",
+		"func (jbobject *LocalFoo) Count() int {",
+		"func (jbobject *LocalFoo) Name() (string, error) {",
+		"func LocalFooNAME() string {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// proxy constructors return the error of jagrt.NewProxy
+	out = generateJavapWith("public interface local.Listener {\n  public abstract void changed();\n}\n", nil, NewAbstractClassList(nil), &StringGenerator{PkgName: "test", NoPanic: true})
+	if strings.Contains(out, "panic(") {
+		t.Fatalf("panic in:\n%s", out)
+	}
+	if want := "func NewLocalListenerProxy(impl LocalListenerInterface) (proxy *LocalListener, release func(), err error) {"; !strings.Contains(out, want) || !strings.Contains(out, "\tif err != nil {\n\t\treturn nil, nil, err\n\t}\n\tproxy = &LocalListener{}") {
+		t.Fatalf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestFields(t *testing.T) {
+	out := generateJavap(`public class local.Foo {
+  public int count;
+  public final java.lang.String name;
+  public static int total;
+  public static final java.lang.String NAME;
+  public int count();
+}
+`, nil, "")
+	for _, want := range []string{
+		"// CountField returns the Java field count.\nfunc (jbobject *LocalFoo) CountField() int {\n\tjret, err := jbobject.GetFieldInt(\"count\")\n",
+		"// SetCountField sets the Java field count.\nfunc (jbobject *LocalFoo) SetCountField(v int) {\n\terr := jbobject.SetFieldInt(\"count\", v)\n",
+		"func (jbobject *LocalFoo) Name() string {\n\tjret, err := jbobject.GetFieldObj(\"name\", \"java.lang.String\")\n",
+		"func LocalFooTotal() int {",
+		"func SetLocalFooTotal(v int) {\n\terr := javabind.SetFieldStaticInt(\"local.Foo\", \"total\", v)\n",
+		"func LocalFooNAME() string {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"SetName", "SetLocalFooNAME"} {
+		if strings.Contains(out, unwanted) {
+			t.Fatalf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	out := generateJavap(`public class local.Foo {
+  public static final int ANSWER = 42;
+  public static final long BIG = -9223372036854775808l;
+  public static final double HALF = 0.5d;
+  public static final float NAN = NaNf;
+  public static final boolean ON = true;
+  public static final java.lang.String NAME = "a;b {\"c\"}é";
+  public static final java.lang.String NOT_CONSTANT;
+}
+`, nil, "")
+	for _, want := range []string{
+		"// LocalFooANSWER is the value of the Java constant ANSWER.\nconst LocalFooANSWER int = 42\n",
+		"const LocalFooBIG int64 = -9223372036854775808\n",
+		"const LocalFooHALF float64 = 0.5\n",
+		"func LocalFooNAN() float32 {",
+		"const LocalFooON bool = true\n",
+		"const LocalFooNAME string = \"a;b {\\\"c\\\"}é\"\n",
+		"func LocalFooNOT_CONSTANT() string {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	for _, v := range []string{"", "tab\tquote\"", "é😀\x00"} {
+		if got, err := javaUnquote(javaQuote(utf16.Encode([]rune(v)), '"')); err != nil || got != v {
+			t.Errorf("round trip of %q: %q %v", v, got, err)
+		}
+	}
+	if got, err := javaUnquote(`"\101\0\uuu0042\ud83d\ude00"`); err != nil || got != "A\x00B😀" {
+		t.Errorf("%q %v", got, err)
+	}
+}
+
+func TestEnum(t *testing.T) {
+	classes := NewAbstractClassList(nil)
+	out := generateJavapWith(`public final class local.Color extends java.lang.Enum<local.Color> {
+  public static final local.Color RED;
+  public static final local.Color GREEN;
+  public static final int COUNT = 2;
+  public static final local.Color DEFAULT_COLOR;
+  public static local.Color[] values();
+  public static local.Color valueOf(java.lang.String);
+  public int rgb();
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"type LocalColor int\n\nconst (\n\tLocalColorRED LocalColor = iota\n\tLocalColorGREEN\n)\n",
+		"var localColorNames = []string{\"RED\", \"GREEN\"}",
+		// a field of the enum's type after another member is not a constant
+		"func LocalColorDEFAULT_COLOR() LocalColor {",
+		"func (e LocalColor) Object() (*LocalColorObject, error) {",
+		"type LocalColorObject struct {\n\t*javabind.Callable\n}",
+		"func (jbobject *LocalColorObject) Rgb() int {",
+		"func LocalColorValueOf(a string) LocalColor {",
+		"retconv := NewJavaToGoLocalColor()\n",
+		"jagrt.RegisterConverter(LocalColor(0), NewGoToJavaLocalColor, NewJavaToGoLocalColor)",
+		"const LocalColorCOUNT int = 2\n",
+		"import \"strconv\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "func LocalColorRED") {
+		t.Fatalf("getter for constant in:\n%s", out)
+	}
+
+	out = generateJavapWith(`public class local.Canvas {
+  public void fill(local.Color);
+  public local.Color[] colors();
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"func (jbobject *LocalCanvas) Fill(a LocalColor)  {\n\tconv_a := NewGoToJavaLocalColor()\n",
+		"javabind.CastObject(conv_a.Value(), \"local.Color\")",
+		"func (jbobject *LocalCanvas) Colors() []LocalColor {",
+		"javabind.NewJavaToGoObjectArray(NewJavaToGoLocalColor())",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	classes := NewAbstractClassList(nil)
+	classes.AddClass("local.Pair", Record)
+	out := generateJavapWith(`public final class local.Point extends java.lang.Record {
+  public local.Point(int, java.lang.String);
+  public final java.lang.String toString();
+  public int x();
+  public java.lang.String label();
+  public double length();
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"type LocalPoint struct {\n\tX int\n\tLabel string\n}\n",
+		"func (r LocalPoint) Object() *LocalPointObject {\n\treturn NewLocalPointObject(r.X, r.Label)\n}",
+		"func localPointValue(jbobject *javabind.Callable) (LocalPoint, error) {\n\tvar r LocalPoint\n\t{\n\t\tjret, err := jbobject.CallInt(\"x\")\n",
+		"\t\tr.Label = *dst\n\t}\n\treturn r, nil\n}",
+		"jagrt.RegisterConverter(LocalPoint{}, NewGoToJavaLocalPoint, NewJavaToGoLocalPoint)",
+		"type LocalPointObject struct {\n\t*javabind.Callable\n}",
+		"func (jbobject *LocalPointObject) Length() float64 {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	out = generateJavapWith(`public final class local.Pair<A, B> extends java.lang.Record {
+  public local.Pair(A, B) throws java.io.IOException;
+  public A first();
+  public B second();
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"type LocalPair[A, B any] struct {\n\tFirst A\n\tSecond B\n}\n",
+		"func (r LocalPair[A, B]) Object() (*LocalPairObject[A, B], error) {\n\treturn NewLocalPairObject[A, B](r.First, r.Second)\n}",
+		"func NewGoToJavaLocalPair[A, B any]() javabind.Converter {",
+		"return localPairValue[A, B](jbobject)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "RegisterConverter") {
+		t.Fatalf("generic record registered in:\n%s", out)
+	}
+
+	out = generateJavapWith(`public class local.Canvas {
+  public local.Pair<java.lang.String, local.Pair<java.lang.Integer, java.lang.Integer>> pair();
+  public void put(local.Pair<java.lang.String, java.lang.String>);
+}
+`, nil, classes, &StringGenerator{PkgName: "test"})
+	for _, want := range []string{
+		"func (jbobject *LocalCanvas) Pair() LocalPair[string, LocalPair[int, int]] {",
+		"retconv := NewJavaToGoLocalPair[string, LocalPair[int, int]]()",
+		"func (jbobject *LocalCanvas) Put(a LocalPair[string, string])  {\n\tconv_a := NewGoToJavaLocalPair[string, string]()\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestNestedClass(t *testing.T) {
+	out := generateJavap(`public class local.Outer$Inner {
+  public local.Outer$Inner(local.Outer, int);
+  public local.Outer$Inner$Deep deep();
+  public java.util.Map$Entry<java.lang.String, java.lang.String> entry();
+}
+`, nil, "")
+	for _, want := range []string{
+		"type LocalOuterInner struct {",
+		"func NewLocalOuterInner(outer *LocalOuter, b int) (*LocalOuterInner) {",
+		`javabind.Env.NewInstanceStr("local.Outer$Inner", javabind.CastObject(conv_outer.Value(), "local.Outer"), b)`,
+		"func (jbobject *LocalOuterInner) Deep() *LocalOuterInnerDeep {",
+		`jbobject.CallObj("deep", "local.Outer$Inner$Deep")`,
+		"javabind.NewJavaToGoMap_Entry(",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestUpcasts(t *testing.T) {
+	abstract := "java.lang.Runnable\nlocal.Shape\nother.Shape\nlocal.A\nlocal.B\n"
+	out := generateJavap(`public class local.Foo extends local.Base implements java.lang.Runnable, local.Shape, other.Shape, java.lang.Comparable<local.Foo> {
+  public void run();
+  public int asRunnable();
+}
+`, nil, abstract)
+	for _, want := range []string{
+		"type LocalFoo struct {\n\tLocalBase\n}",
+		"func (jbobject *LocalFoo) AsJavaLangRunnable() *JavaLangRunnable {\n\tx := &JavaLangRunnable{}\n\tx.Callable = jbobject.Callable\n\treturn x\n}",
+		"func (jbobject *LocalFoo) AsShape() *LocalShape {",
+		"func (jbobject *LocalFoo) AsOtherShape() *OtherShape {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	// unknown interfaces don't get one
+	if strings.Contains(out, "Comparable") {
+		t.Fatalf("upcast to unknown interface in:\n%s", out)
+	}
+
+	out = generateJavap(`public interface local.A extends local.B, local.Shape {
+}
+`, nil, abstract)
+	if want := "type LocalA struct {\n\tLocalB\n}"; !strings.Contains(out, want) || strings.Contains(out, "AsB") || !strings.Contains(out, "func (jbobject *LocalA) AsShape() *LocalShape {") {
+		t.Fatalf("bad interface upcasts in:\n%s", out)
+	}
+
+	// the subclasses a sealed class permits are not its interfaces
+	out = generateJavap(`public abstract sealed class local.Shape implements local.A permits local.Circle,local.Square {
+}
+`, nil, abstract+"local.Circle\nlocal.Square\n")
+	if strings.Contains(out, "AsCircle") || strings.Contains(out, "AsSquare") || !strings.Contains(out, "func (jbobject *LocalShape) AsA() *LocalA {") {
+		t.Fatalf("bad sealed class upcasts in:\n%s", out)
+	}
+
+	// a superclass converted to a Go type is not embedded
+	out = generateJavap(`public class local.Day extends java.util.Date {
+}
+`, nil, "")
+	if want := "type LocalDay struct {\n\t*javabind.Callable\n}"; !strings.Contains(out, want) {
+		t.Fatalf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestCasts(t *testing.T) {
+	out := generateJavap(`public class local.Foo extends local.Base {
+  public void run();
+}
+`, nil, "")
+	for _, want := range []string{
+		"import \"github.com/timob/javabind\"\nimport \"github.com/timob/jag/jagrt\"\n",
+		"func IsInstanceLocalFoo(obj *javabind.Callable) bool {\n\tok, err := jagrt.IsInstance(obj, \"local.Foo\")\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\treturn ok\n}",
+		"func CastToLocalFoo(obj *javabind.Callable) (*LocalFoo, bool) {\n\tok, err := jagrt.IsInstance(obj, \"local.Foo\")\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tif !ok {\n\t\treturn nil, false\n\t}\n\tx := &LocalFoo{}\n\tx.Callable = obj\n\treturn x, true\n}",
+		"jagrt.RegisterClass(\"local.Foo\", func(obj *javabind.Callable) interface{} {\n\t\tx := &LocalFoo{}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// objects are never just instances of generic classes or interfaces
+	for _, javap := range []string{
+		"public class local.Box<T> {\n}\n",
+		"public interface local.Shape {\n}\n",
+	} {
+		out = generateJavap(javap, nil, "")
+		if !strings.Contains(out, "func CastToLocal") || strings.Contains(out, "RegisterClass") {
+			t.Fatalf("bad casts in:\n%s", out)
+		}
+	}
+	if want := "func CastToLocalBox[T any](obj *javabind.Callable) (*LocalBox[T], bool) {"; !strings.Contains(generateJavap("public class local.Box<T> {\n}\n", nil, ""), want) {
+		t.Fatalf("missing %q", want)
+	}
+}
@@ -0,0 +1,161 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeParam is a type parameter of a generic class or method, eg T in
+// <T extends java.lang.Number>. Bounds is empty for an unbounded parameter.
+type TypeParam struct {
+	Name   string
+	Bounds []*JavaType
+}
+
+type TypeParams []TypeParam
+
+// splitTopLevel splits s at sep where it is not inside <>.
+func splitTopLevel(s string, sep string) (parts []string) {
+	depth := 0
+	last := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '<':
+			depth++
+		case s[i] == '>':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, strings.TrimSpace(s[last:i]))
+			last = i + len(sep)
+		}
+	}
+	return append(parts, strings.TrimSpace(s[last:]))
+}
+
+// ParseTypeParams parses a type parameter list the way javap prints it, eg
+// "<K, V extends java.lang.Comparable<V>>".
+func ParseTypeParams(s string) (TypeParams, error) {
+	if !strings.HasPrefix(s, "<") || !strings.HasSuffix(s, ">") {
+		return nil, fmt.Errorf("bad type parameters %q", s)
+	}
+	var tps TypeParams
+	for _, part := range splitTopLevel(s[1:len(s)-1], ",") {
+		fields := strings.SplitN(part, " ", 3)
+		if fields[0] == "" || len(fields) == 2 || len(fields) == 3 && fields[1] != "extends" {
+			return nil, fmt.Errorf("bad type parameters %q", s)
+		}
+		tp := TypeParam{Name: fields[0]}
+		if len(fields) == 3 {
+			for _, bound := range splitTopLevel(fields[2], "&") {
+				t, err := ParseJavaType(bound)
+				if err != nil {
+					return nil, err
+				}
+				tp.Bounds = append(tp.Bounds, t)
+			}
+		}
+		tps = append(tps, tp)
+	}
+	return tps, nil
+}
+
+// String returns the list as javap prints it.
+func (tps TypeParams) String() string {
+	if len(tps) == 0 {
+		return ""
+	}
+	parts := make([]string, len(tps))
+	for i, tp := range tps {
+		parts[i] = tp.Name
+		for j, bound := range tp.Bounds {
+			if j == 0 {
+				parts[i] += " extends "
+			} else {
+				parts[i] += " & "
+			}
+			parts[i] += bound.String()
+		}
+	}
+	return "<" + strings.Join(parts, ", ") + ">"
+}
+
+func (tps TypeParams) lookup(name string) (TypeParam, bool) {
+	for _, tp := range tps {
+		if tp.Name == name {
+			return tp, true
+		}
+	}
+	return TypeParam{}, false
+}
+
+// erasure returns the class a type variable erases to, its first bound
+// without type arguments or java.lang.Object.
+func (tps TypeParams) erasure(name string) string {
+	for i := 0; i <= len(tps); i++ {
+		tp, ok := tps.lookup(name)
+		if !ok {
+			return name
+		}
+		if len(tp.Bounds) == 0 {
+			return "java.lang.Object"
+		}
+		name = tp.Bounds[0].Name
+	}
+	// bounds refer to each other in a loop
+	return "java.lang.Object"
+}
+
+// Erase replaces the type variables in a Java type with their erasure, so
+// "java.util.List<T>" becomes "java.util.List<java.lang.Object>" when T is
+// unbounded.
+func (tps TypeParams) Erase(t string) string {
+	if len(tps) == 0 {
+		return t
+	}
+	return tps.EraseType(parseJavaTypeName(t)).String()
+}
+
+// EraseType is Erase for a parsed type.
+func (tps TypeParams) EraseType(t *JavaType) *JavaType {
+	e := *t
+	switch t.Kind {
+	case WildcardType:
+		if t.Bound != nil {
+			e.Bound = tps.EraseType(t.Bound)
+		}
+	case ClassType:
+		if _, ok := tps.lookup(t.Name); ok && len(t.Args) == 0 {
+			e.Name = tps.erasure(t.Name)
+			break
+		}
+		e.Args = make([]*JavaType, len(t.Args))
+		for i, arg := range t.Args {
+			e.Args[i] = tps.EraseType(arg)
+		}
+	}
+	return &e
+}
+
+// EraseParams returns params with their types erased.
+func (tps TypeParams) EraseParams(params Params) Params {
+	if len(tps) == 0 {
+		return params
+	}
+	z := make(Params, len(params))
+	for i, p := range params {
+		z[i] = Param{p.Name, tps.Erase(p.Type)}
+	}
+	return z
+}
+
+// EraseAll returns types erased.
+func (tps TypeParams) EraseAll(types []string) []string {
+	if len(tps) == 0 {
+		return types
+	}
+	z := make([]string, len(types))
+	for i, t := range types {
+		z[i] = tps.Erase(t)
+	}
+	return z
+}
@@ -0,0 +1,84 @@
+package jag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeParams(t *testing.T) {
+	tps, err := ParseTypeParams("<K, V extends java.lang.Comparable<V> & java.io.Serializable, E extends V>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tps) != 3 || tps[0].Name != "K" || len(tps[0].Bounds) != 0 || len(tps[1].Bounds) != 2 || tps[1].Bounds[0].String() != "java.lang.Comparable<V>" {
+		t.Fatalf("%+v", tps)
+	}
+	if s := tps.String(); s != "<K, V extends java.lang.Comparable<V> & java.io.Serializable, E extends V>" {
+		t.Fatal(s)
+	}
+
+	for _, v := range [][2]string{
+		{"K", "java.lang.Object"},
+		{"V[]", "java.lang.Comparable[]"},
+		{"E...", "java.lang.Comparable..."},
+		{"java.util.Map<K, java.util.List<V>>", "java.util.Map<java.lang.Object, java.util.List<java.lang.Comparable>>"},
+		{"local.K", "local.K"},
+		{"int", "int"},
+	} {
+		if got := tps.Erase(v[0]); got != v[1] {
+			t.Errorf("Erase(%q) = %q, want %q", v[0], got, v[1])
+		}
+	}
+
+	if _, err := ParseTypeParams("<T super X>"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseGenericMethod(t *testing.T) {
+	sig, err := ParseClass(strings.NewReader(`public class local.Foo {
+  public <T> T get(java.lang.Class<T>);
+  public static <T extends java.lang.Number> java.util.List<T> list(T...);
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.Methods) != 2 {
+		t.Fatalf("%+v", sig.Methods)
+	}
+	m := sig.Methods[0]
+	if m.Name != "get" || m.Return != "java.lang.Object" || m.Params[0].Type != "java.lang.Class<java.lang.Object>" || m.TypeParams.String() != "<T>" {
+		t.Fatalf("%+v", m)
+	}
+	m = sig.Methods[1]
+	if m.Name != "list" || !m.Static || m.Return != "java.util.List<java.lang.Number>" || m.Params[0].Type != "java.lang.Number..." {
+		t.Fatalf("%+v", m)
+	}
+}
+
+func TestReadGenericMethodSignature(t *testing.T) {
+	tps, params, ret, _, err := (&signatureReader{s: "<T:Ljava/lang/Object;U::Ljava/lang/Comparable<TU;>;>(TT;Ljava/util/List<TU;>;)TU;"}).readMethod()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tps.String() != "<T, U extends java.lang.Comparable<U>>" {
+		t.Fatal(tps.String())
+	}
+	if len(params) != 2 || tps.Erase(params[1]) != "java.util.List<java.lang.Comparable>" || tps.Erase(ret) != "java.lang.Comparable" {
+		t.Fatal(params, ret)
+	}
+}
+
+func TestParseGenericClass(t *testing.T) {
+	sig, err := ParseClass(strings.NewReader(`public class local.Pair<K, V extends java.lang.Comparable<V>> {
+  public K key();
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.ClassName != "local.Pair" || sig.TypeParams.String() != "<K, V extends java.lang.Comparable<V>>" || sig.Methods[0].Return != "K" {
+		t.Fatalf("%+v", sig)
+	}
+}
@@ -0,0 +1,88 @@
+package jagrt
+
+import (
+	"sync"
+
+	"github.com/timob/javabind"
+)
+
+var classes = struct {
+	sync.Mutex
+	wrap map[string]func(obj *javabind.Callable) interface{}
+}{wrap: make(map[string]func(obj *javabind.Callable) interface{})}
+
+// RegisterClass sets the function wrapping objects of a Java class in its
+// generated Go type, for Wrap. Generated code calls it from init for every
+// class but generic ones and interfaces.
+func RegisterClass(class string, wrap func(obj *javabind.Callable) interface{}) {
+	classes.Lock()
+	defer classes.Unlock()
+	classes.wrap[class] = wrap
+}
+
+// IsInstance reports whether obj is an instance of class, a subclass of it
+// or an implementation if it is an interface. It is false for a nil obj. The
+// error is from the JVM, eg a ClassNotFoundException for an unknown class.
+func IsInstance(obj *javabind.Callable, class string) (bool, error) {
+	if obj == nil {
+		return false, nil
+	}
+	c, err := classObject(class)
+	if err != nil {
+		return false, err
+	}
+	conv := javabind.NewGoToJavaCallable()
+	if err := conv.Convert(obj); err != nil {
+		return false, err
+	}
+	ok, err := c.CallBoolean("isInstance", javabind.CastObject(conv.Value(), "java.lang.Object"))
+	conv.CleanUp()
+	if err != nil {
+		return false, NewException(err)
+	}
+	return ok, nil
+}
+
+// Wrap returns obj in the generated type of its class, or of its closest
+// superclass that has one, so an object returned as a supertype can be used
+// as what it is:
+//
+//	v, err := jagrt.Wrap(obj.Callable)
+//	if foo, ok := v.(*LocalFoo); ok {
+//
+// obj is returned as it is when no class has a generated type.
+func Wrap(obj *javabind.Callable) (interface{}, error) {
+	jclass, err := obj.CallObj("getClass", "java.lang.Class")
+	if err != nil {
+		return nil, NewException(err)
+	}
+	class, err := callable(jclass)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		jname, err := class.CallObj("getName", "java.lang.String")
+		if err != nil {
+			return nil, NewException(err)
+		}
+		name, err := goString(jname)
+		if err != nil {
+			return nil, err
+		}
+		classes.Lock()
+		wrap := classes.wrap[name]
+		classes.Unlock()
+		if wrap != nil {
+			return wrap(obj), nil
+		} else if name == "java.lang.Object" {
+			return obj, nil
+		}
+		jsuper, err := class.CallObj("getSuperclass", "java.lang.Class")
+		if err != nil {
+			return nil, NewException(err)
+		}
+		if class, err = callable(jsuper); err != nil {
+			return nil, err
+		}
+	}
+}
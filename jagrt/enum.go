@@ -0,0 +1,123 @@
+package jagrt
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/timob/javabind"
+)
+
+var converters = struct {
+	sync.Mutex
+	goToJava map[reflect.Type]func() javabind.Converter
+	javaToGo map[reflect.Type]func() javabind.Converter
+}{goToJava: make(map[reflect.Type]func() javabind.Converter), javaToGo: make(map[reflect.Type]func() javabind.Converter)}
+
+// RegisterConverter sets the converters NewGoToJava and NewJavaToGo use for
+// the type of value. Generated code calls it from init for the Go types of
+// enums and records.
+func RegisterConverter(value interface{}, goToJava, javaToGo func() javabind.Converter) {
+	converters.Lock()
+	defer converters.Unlock()
+	t := reflect.TypeOf(value)
+	converters.goToJava[t] = goToJava
+	converters.javaToGo[t] = javaToGo
+}
+
+// registered returns the converter registered for t, nil if there is none.
+func registered(m map[reflect.Type]func() javabind.Converter, t reflect.Type) javabind.Converter {
+	converters.Lock()
+	newConverter := m[t]
+	converters.Unlock()
+	if newConverter == nil {
+		return nil
+	}
+	return newConverter()
+}
+
+// EnumObject returns the constant of a Java enum class with the given name.
+func EnumObject(class, name string) (*javabind.Callable, error) {
+	conv := javabind.NewGoToJavaString()
+	if err := conv.Convert(name); err != nil {
+		return nil, err
+	}
+	jret, err := javabind.CallStaticObj(class, "valueOf", class, javabind.CastObject(conv.Value(), "java.lang.String"))
+	conv.CleanUp()
+	if err != nil {
+		return nil, NewException(err)
+	}
+	return callable(jret)
+}
+
+// NewGoToJavaEnum returns the converter of Go enum values to the constants
+// of a Java enum class. The values are converted by name, using their
+// String method.
+func NewGoToJavaEnum(class string) javabind.Converter {
+	return &goToJavaEnum{Converter: javabind.NewGoToJavaCallable(), class: class}
+}
+
+type goToJavaEnum struct {
+	javabind.Converter
+	class string
+}
+
+func (c *goToJavaEnum) Convert(value interface{}) error {
+	obj, err := EnumObject(c.class, value.(fmt.Stringer).String())
+	if err != nil {
+		return err
+	}
+	return c.Converter.Convert(obj)
+}
+
+// NewJavaToGoEnum returns the converter of Java enum constants to a Go enum
+// type, the value of a constant is the index of its name in names.
+func NewJavaToGoEnum(names []string) javabind.Converter {
+	return &javaToGoEnum{Converter: javabind.NewJavaToGoCallable(), names: names}
+}
+
+type javaToGoEnum struct {
+	javabind.Converter
+	names    []string
+	dst      reflect.Value
+	callable *javabind.Callable
+}
+
+func (c *javaToGoEnum) Dest(dst interface{}) {
+	c.dst = reflect.ValueOf(dst).Elem()
+	c.callable = &javabind.Callable{}
+	c.Converter.Dest(c.callable)
+}
+
+func (c *javaToGoEnum) Convert(value interface{}) error {
+	if err := c.Converter.Convert(value); err != nil {
+		return err
+	}
+	jname, err := c.callable.CallObj("name", "java.lang.String")
+	if err != nil {
+		return NewException(err)
+	}
+	name, err := goString(jname)
+	if err != nil {
+		return err
+	}
+	for i, n := range c.names {
+		if n == name {
+			c.dst.SetInt(int64(i))
+			return nil
+		}
+	}
+	return fmt.Errorf("jagrt: unknown enum constant %s", name)
+}
+
+// goString converts a java.lang.String returned by a call.
+func goString(jobj interface{}) (string, error) {
+	conv := javabind.NewJavaToGoString()
+	dst := new(string)
+	conv.Dest(dst)
+	if err := conv.Convert(jobj); err != nil {
+		return "", err
+	}
+	conv.CleanUp()
+	return *dst, nil
+}
@@ -0,0 +1,217 @@
+package jagrt
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/timob/javabind"
+)
+
+// Exception is a Java exception thrown by a call. Generated code returns it
+// wrapped in the error type generated for its class when there is one, eg
+// *JavaIoIOExceptionError, so
+//
+//	var e *JavaIoIOExceptionError
+//	if errors.As(err, &e) {
+//
+// matches an IOException or any subclass of it.
+type Exception struct {
+	// Java class name, empty when the error is not a Java exception
+	Class   string
+	Message string
+	// the exception that caused this one, nil if there is none
+	Cause *Exception
+	// the error returned by the call
+	Err error
+}
+
+func (e *Exception) Error() string {
+	if e.Class == "" {
+		return e.Message
+	} else if e.Message == "" {
+		return e.Class
+	}
+	return e.Class + ": " + e.Message
+}
+
+// Unwrap returns the cause.
+func (e *Exception) Unwrap() error {
+	if e.Cause == nil {
+		return nil
+	}
+	return exceptionError(e.Cause)
+}
+
+// Is reports whether e is an instance of the class of an *Exception target,
+// eg errors.Is(err, &jagrt.Exception{Class: "java.io.IOException"}).
+func (e *Exception) Is(target error) bool {
+	t, ok := target.(*Exception)
+	if !ok {
+		return false
+	}
+	ok, err := instanceOf(e.Class, t.Class)
+	return err == nil && ok
+}
+
+// As sets target, a pointer to a generated error type, if e is an instance
+// of its class.
+func (e *Exception) As(target interface{}) bool {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr {
+		return false
+	}
+	exceptions.Lock()
+	class, ok := exceptions.classes[v.Type().Elem()]
+	newError := exceptions.types[class]
+	exceptions.Unlock()
+	if !ok {
+		return false
+	}
+	if ok, err := instanceOf(e.Class, class); err != nil || !ok {
+		return false
+	}
+	v.Elem().Set(reflect.ValueOf(newError(e)))
+	return true
+}
+
+var exceptions = struct {
+	sync.Mutex
+	types   map[string]func(e *Exception) error
+	classes map[reflect.Type]string
+}{types: make(map[string]func(e *Exception) error), classes: make(map[reflect.Type]string)}
+
+// RegisterException sets the function wrapping exceptions of a Java class
+// in its generated error type. Generated code calls it from init.
+func RegisterException(class string, newError func(e *Exception) error) {
+	exceptions.Lock()
+	defer exceptions.Unlock()
+	exceptions.types[class] = newError
+	exceptions.classes[reflect.TypeOf(newError(&Exception{}))] = class
+}
+
+// javaException is the error javabind returns for a call that threw a Java
+// exception, Throwable returns the exception object.
+type javaException interface {
+	error
+	Throwable() *javabind.Callable
+}
+
+// maxCauses limits the causes read, which can form a cycle.
+const maxCauses = 32
+
+// NewException returns the error for err, returned by a call that threw a
+// Java exception. The class, message and causes are read from the exception
+// object through JNI. Other errors, and exceptions that can't be read, give
+// an *Exception with only the message of err.
+func NewException(err error) error {
+	var t javaException
+	if !errors.As(err, &t) {
+		return &Exception{Message: err.Error(), Err: err}
+	}
+	e, jerr := readException(t.Throwable(), maxCauses)
+	if jerr != nil {
+		return &Exception{Message: err.Error(), Err: err}
+	}
+	for c := e; c != nil; c = c.Cause {
+		c.Err = err
+	}
+	return exceptionError(e)
+}
+
+// readException reads the class name, message and up to depth causes of a
+// java.lang.Throwable.
+func readException(obj *javabind.Callable, depth int) (*Exception, error) {
+	jclass, err := obj.CallObj("getClass", "java.lang.Class")
+	if err != nil {
+		return nil, err
+	}
+	class, err := callable(jclass)
+	if err != nil {
+		return nil, err
+	}
+	jname, err := class.CallObj("getName", "java.lang.String")
+	if err != nil {
+		return nil, err
+	}
+	e := &Exception{}
+	if e.Class, err = goString(jname); err != nil {
+		return nil, err
+	}
+
+	jmessage, err := obj.CallObj("getMessage", "java.lang.String")
+	if err != nil {
+		return nil, err
+	} else if jmessage != nil {
+		if e.Message, err = goString(jmessage); err != nil {
+			return nil, err
+		}
+	}
+
+	jcause, err := obj.CallObj("getCause", "java.lang.Throwable")
+	if err != nil {
+		return nil, err
+	} else if jcause != nil && depth > 0 {
+		cause, err := callable(jcause)
+		if err != nil {
+			return nil, err
+		}
+		if e.Cause, err = readException(cause, depth-1); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// exceptionError returns e in the error type registered for its class.
+func exceptionError(e *Exception) error {
+	exceptions.Lock()
+	newError := exceptions.types[e.Class]
+	exceptions.Unlock()
+	if newError == nil {
+		return e
+	}
+	return newError(e)
+}
+
+// instanceOf reports whether class is super or a subclass of it, asking the
+// JVM when the names differ.
+func instanceOf(class, super string) (bool, error) {
+	if class == super {
+		return class != "", nil
+	} else if class == "" || super == "" {
+		return false, nil
+	}
+	sub, err := classObject(class)
+	if err != nil {
+		return false, err
+	}
+	sup, err := classObject(super)
+	if err != nil {
+		return false, err
+	}
+	conv := javabind.NewGoToJavaCallable()
+	if err := conv.Convert(sub); err != nil {
+		return false, err
+	}
+	ok, err := sup.CallBoolean("isAssignableFrom", javabind.CastObject(conv.Value(), "java.lang.Class"))
+	conv.CleanUp()
+	if err != nil {
+		return false, NewException(err)
+	}
+	return ok, nil
+}
+
+// classObject returns the java.lang.Class for a class name.
+func classObject(name string) (*javabind.Callable, error) {
+	conv := javabind.NewGoToJavaString()
+	if err := conv.Convert(name); err != nil {
+		return nil, err
+	}
+	jret, err := javabind.CallStaticObj("java.lang.Class", "forName", "java.lang.Class", javabind.CastObject(conv.Value(), "java.lang.String"))
+	conv.CleanUp()
+	if err != nil {
+		return nil, NewException(err)
+	}
+	return callable(jret)
+}
@@ -0,0 +1,35 @@
+package jagrt
+
+import (
+	"errors"
+	"testing"
+)
+
+type ioError struct {
+	*Exception
+}
+
+func TestException(t *testing.T) {
+	RegisterException("java.io.IOException", func(e *Exception) error { return &ioError{e} })
+
+	err := exceptionError(&Exception{
+		Class:   "java.io.IOException",
+		Message: "read failed: bad",
+		Cause:   &Exception{Class: "java.lang.IllegalStateException", Message: "closed"},
+	})
+	var ioErr *ioError
+	if !errors.As(err, &ioErr) || ioErr.Class != "java.io.IOException" || ioErr.Message != "read failed: bad" {
+		t.Fatalf("%#v", err)
+	}
+	if !errors.Is(err, &Exception{Class: "java.lang.IllegalStateException"}) {
+		t.Fatal("cause not found")
+	}
+	if cause := ioErr.Cause; cause == nil || cause.Message != "closed" || cause.Cause != nil {
+		t.Fatalf("%#v", cause)
+	}
+
+	err = NewException(errors.New("no such method"))
+	if e, ok := err.(*Exception); !ok || e.Class != "" || err.Error() != "no such method" {
+		t.Fatalf("%#v", err)
+	}
+}
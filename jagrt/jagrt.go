@@ -0,0 +1,124 @@
+// Package jagrt has run time support for code generated by jagen.
+package jagrt
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/timob/javabind"
+)
+
+var (
+	callableType = reflect.TypeOf((*javabind.Callable)(nil))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// isObject reports whether t is a pointer to a generated type for a Java
+// class, these embed *javabind.Callable directly or through their superclass.
+func isObject(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := t.Elem().FieldByName("Callable")
+	return ok && f.Type == callableType
+}
+
+// NewGoToJava returns the converter for Go values of type T. Generated code
+// uses it where a generic class has a Java type variable, T is the Go type
+// argument.
+func NewGoToJava[T any]() javabind.Converter {
+	return goToJava(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// NewJavaToGo returns the converter for Java objects to Go values of type T.
+func NewJavaToGo[T any]() javabind.Converter {
+	return javaToGo(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func goToJava(t reflect.Type) javabind.Converter {
+	if c := registered(converters.goToJava, t); c != nil {
+		return c
+	}
+	if isObject(t) {
+		return javabind.NewGoToJavaCallable()
+	}
+	if t == timeType {
+		return javabind.NewGoToJavaDate()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return javabind.NewGoToJavaString()
+	case reflect.Bool:
+		return javabind.NewGoToJavaBoolean()
+	case reflect.Int:
+		return javabind.NewGoToJavaInteger()
+	case reflect.Int64:
+		return javabind.NewGoToJavaLong()
+	case reflect.Float32:
+		return javabind.NewGoToJavaFloat()
+	case reflect.Float64:
+		return javabind.NewGoToJavaDouble()
+	case reflect.Slice:
+		return javabind.NewGoToJavaList(goToJava(t.Elem()))
+	case reflect.Map:
+		return javabind.NewGoToJavaMap(goToJava(t.Key()), goToJava(t.Elem()))
+	}
+	panic(fmt.Sprintf("jagrt: no Java conversion for Go type %s", t))
+}
+
+func javaToGo(t reflect.Type) javabind.Converter {
+	if c := registered(converters.javaToGo, t); c != nil {
+		return c
+	}
+	if isObject(t) {
+		return &javaToGoObject{Converter: javabind.NewJavaToGoCallable(), t: t}
+	}
+	if t == timeType {
+		return javabind.NewJavaToGoDate()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return javabind.NewJavaToGoString()
+	case reflect.Bool:
+		return javabind.NewJavaToGoBoolean()
+	case reflect.Int:
+		return javabind.NewJavaToGoInteger()
+	case reflect.Int64:
+		return javabind.NewJavaToGoLong()
+	case reflect.Float32:
+		return javabind.NewJavaToGoFloat()
+	case reflect.Float64:
+		return javabind.NewJavaToGoDouble()
+	case reflect.Slice:
+		return javabind.NewJavaToGoList(javaToGo(t.Elem()))
+	case reflect.Map:
+		return javabind.NewJavaToGoMap(javaToGo(t.Key()), javaToGo(t.Elem()))
+	}
+	panic(fmt.Sprintf("jagrt: no Java conversion for Go type %s", t))
+}
+
+// javaToGoObject converts to a generated type, the callable converter fills
+// in a *javabind.Callable which is then wrapped in a new value of the type.
+type javaToGoObject struct {
+	javabind.Converter
+	t        reflect.Type
+	dst      reflect.Value
+	callable *javabind.Callable
+}
+
+func (c *javaToGoObject) Dest(dst interface{}) {
+	c.dst = reflect.ValueOf(dst).Elem()
+	c.callable = &javabind.Callable{}
+	c.Converter.Dest(c.callable)
+}
+
+func (c *javaToGoObject) Convert(value interface{}) error {
+	if err := c.Converter.Convert(value); err != nil {
+		return err
+	}
+	x := reflect.New(c.t.Elem())
+	x.Elem().FieldByName("Callable").Set(reflect.ValueOf(c.callable))
+	c.dst.Set(x)
+	return nil
+}
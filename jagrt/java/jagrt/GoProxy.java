@@ -0,0 +1,113 @@
+package jagrt;
+
+import java.lang.reflect.InvocationHandler;
+import java.lang.reflect.Method;
+import java.lang.reflect.Proxy;
+import java.util.concurrent.BlockingQueue;
+import java.util.concurrent.CountDownLatch;
+import java.util.concurrent.LinkedBlockingQueue;
+
+/**
+ * Java side of the proxies created by the jagrt Go package. Calls on a proxy
+ * are queued, taken by a Go goroutine with next(), and block until Go
+ * completes them. stop() wakes the goroutine with an invocation with id 0
+ * once Go has released its last proxy.
+ */
+public class GoProxy implements InvocationHandler {
+    private static final BlockingQueue<Invocation> calls = new LinkedBlockingQueue<Invocation>();
+
+    private final long id;
+
+    private GoProxy(long id) {
+        this.id = id;
+    }
+
+    public static Object newProxy(String interfaceName, long id) throws ClassNotFoundException {
+        Class<?> iface = Class.forName(interfaceName);
+        return Proxy.newProxyInstance(iface.getClassLoader(), new Class<?>[]{iface}, new GoProxy(id));
+    }
+
+    public static Invocation next() throws InterruptedException {
+        return calls.take();
+    }
+
+    public static void stop() {
+        calls.add(new Invocation());
+    }
+
+    public Object invoke(Object proxy, Method method, Object[] args) throws Throwable {
+        if (method.getDeclaringClass() == Object.class) {
+            if (method.getName().equals("equals")) {
+                return proxy == args[0];
+            } else if (method.getName().equals("hashCode")) {
+                return System.identityHashCode(proxy);
+            }
+            return "GoProxy@" + id;
+        }
+        Invocation call = new Invocation(id, method, args);
+        calls.put(call);
+        return call.await();
+    }
+
+    public static class Invocation {
+        private final long id;
+        private final String method;
+        private final Object[] args;
+        private final CountDownLatch done = new CountDownLatch(1);
+        private Object result;
+        private String error;
+
+        private Invocation() {
+            this.id = 0;
+            this.method = "";
+            this.args = new Object[0];
+        }
+
+        Invocation(long id, Method method, Object[] args) {
+            this.id = id;
+            this.args = args == null ? new Object[0] : args;
+            StringBuilder sb = new StringBuilder(method.getName()).append('(');
+            Class<?>[] types = method.getParameterTypes();
+            for (int i = 0; i < types.length; i++) {
+                if (i > 0) {
+                    sb.append(',');
+                }
+                sb.append(types[i].getTypeName());
+            }
+            this.method = sb.append(')').toString();
+        }
+
+        Object await() throws InterruptedException {
+            done.await();
+            if (error != null) {
+                throw new RuntimeException(error);
+            }
+            return result;
+        }
+
+        public long id() { return id; }
+        /** The method called, as name(type,type) with erased parameter types. */
+        public String method() { return method; }
+
+        public Object arg(int i) { return args[i]; }
+        public int intArg(int i) { return (Integer) args[i]; }
+        public long longArg(int i) { return (Long) args[i]; }
+        public float floatArg(int i) { return (Float) args[i]; }
+        public double doubleArg(int i) { return (Double) args[i]; }
+        public boolean booleanArg(int i) { return (Boolean) args[i]; }
+        public int[] intArrayArg(int i) { return (int[]) args[i]; }
+        public long[] longArrayArg(int i) { return (long[]) args[i]; }
+
+        public void complete(Object v) { result = v; done.countDown(); }
+        public void completeVoid() { done.countDown(); }
+        public void completeInt(int v) { complete(v); }
+        public void completeLong(long v) { complete(v); }
+        public void completeFloat(float v) { complete(v); }
+        public void completeDouble(double v) { complete(v); }
+        public void completeBoolean(boolean v) { complete(v); }
+        public void completeIntArray(int[] v) { complete(v); }
+        public void completeLongArray(long[] v) { complete(v); }
+
+        public void fail(String message) { error = message; done.countDown(); }
+    }
+}
@@ -0,0 +1,224 @@
+package jagrt
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/timob/javabind"
+)
+
+// Invocation is a call of an interface method on a Java proxy, it is
+// jagrt.GoProxy$Invocation in Java. The arguments are read with the arg
+// methods and the call is finished with one of the complete methods or fail,
+// see GoProxy.java.
+type Invocation struct {
+	*javabind.Callable
+}
+
+// Method returns the method called, as name(type,type) with the parameter
+// types erased, eg "compare(java.lang.Object,java.lang.Object)".
+func (inv *Invocation) Method() (string, error) {
+	jret, err := inv.CallObj("method", "java.lang.String")
+	if err != nil {
+		return "", NewException(err)
+	}
+	return goString(jret)
+}
+
+// Fail makes the call throw a RuntimeException in Java.
+func (inv *Invocation) Fail(message string) error {
+	conv := javabind.NewGoToJavaString()
+	if err := conv.Convert(message); err != nil {
+		return err
+	}
+	defer conv.CleanUp()
+	if err := inv.CallVoid("fail", javabind.CastObject(conv.Value(), "java.lang.String")); err != nil {
+		return NewException(err)
+	}
+	return nil
+}
+
+// ErrNoMethod is returned by a Dispatch for a method it doesn't implement.
+var ErrNoMethod = errors.New("jagrt: proxy method not implemented")
+
+// Dispatch handles the calls on a proxy, it reads the arguments, calls the
+// Go implementation and completes the invocation.
+type Dispatch func(inv *Invocation) error
+
+var proxies = struct {
+	sync.Mutex
+	handlers map[int64]Dispatch
+	nextID   int64
+	// a goroutine is taking calls from the GoProxy queue
+	serving bool
+}{handlers: make(map[int64]Dispatch)}
+
+// NewProxy returns a Java object implementing the interface which calls
+// dispatch for each method called on it. release removes dispatch, calls on
+// the object after that fail.
+//
+// The jagrt.GoProxy class from jagrt/java must be on the class path.
+func NewProxy(interfaceName string, dispatch Dispatch) (obj *javabind.Callable, release func(), err error) {
+	proxies.Lock()
+	proxies.nextID++
+	id := proxies.nextID
+	proxies.handlers[id] = dispatch
+	if !proxies.serving {
+		proxies.serving = true
+		go serve()
+	}
+	proxies.Unlock()
+
+	release = func() {
+		proxies.Lock()
+		delete(proxies.handlers, id)
+		last := len(proxies.handlers) == 0
+		proxies.Unlock()
+		// wake serve so it can stop
+		if last {
+			if err := javabind.CallStaticVoid("jagrt.GoProxy", "stop"); err != nil {
+				log.Printf("jagrt: proxy: %s", NewException(err))
+			}
+		}
+	}
+
+	conv := javabind.NewGoToJavaString()
+	if err := conv.Convert(interfaceName); err != nil {
+		release()
+		return nil, nil, err
+	}
+	jret, err := javabind.CallStaticObj("jagrt.GoProxy", "newProxy", "java.lang.Object", javabind.CastObject(conv.Value(), "java.lang.String"), id)
+	conv.CleanUp()
+	if err != nil {
+		release()
+		return nil, nil, NewException(err)
+	}
+	if obj, err = callable(jret); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return obj, release, nil
+}
+
+// callable wraps a Java object returned by a call.
+func callable(jobj interface{}) (*javabind.Callable, error) {
+	conv := javabind.NewJavaToGoCallable()
+	dst := &javabind.Callable{}
+	conv.Dest(dst)
+	if err := conv.Convert(jobj); err != nil {
+		return nil, err
+	}
+	conv.CleanUp()
+	return dst, nil
+}
+
+// attachThread locks the calling goroutine to its OS thread and attaches
+// the thread to the JVM, JNI must not be used from other threads. detach
+// undoes both, a thread that was already attached stays attached.
+func attachThread() (detach func(), err error) {
+	runtime.LockOSThread()
+	attached, err := javabind.AttachCurrentThread()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		if attached {
+			if err := javabind.DetachCurrentThread(); err != nil {
+				log.Printf("jagrt: proxy: %s", err)
+			}
+		}
+		runtime.UnlockOSThread()
+	}, nil
+}
+
+// serve takes calls from the GoProxy queue and dispatches each on its own
+// goroutine. It backs off while taking a call fails, and returns once the
+// last proxy is released.
+func serve() {
+	detach, err := attachThread()
+	if err != nil {
+		log.Printf("jagrt: proxy: %s", err)
+		proxies.Lock()
+		proxies.serving = false
+		proxies.Unlock()
+		return
+	}
+	defer detach()
+
+	var delay time.Duration
+	for {
+		jret, err := javabind.CallStaticObj("jagrt.GoProxy", "next", "jagrt.GoProxy$Invocation")
+		var id int64
+		var inv *Invocation
+		if err == nil {
+			var obj *javabind.Callable
+			if obj, err = callable(jret); err == nil {
+				inv = &Invocation{obj}
+				id, err = inv.CallLong("id")
+			}
+		}
+		if err != nil {
+			log.Printf("jagrt: proxy: %s", NewException(err))
+			if delay < time.Second {
+				delay = delay*2 + 10*time.Millisecond
+			}
+			time.Sleep(delay)
+			continue
+		}
+		delay = 0
+
+		// release queues an invocation with id 0 after the last proxy
+		if id == 0 {
+			proxies.Lock()
+			stop := len(proxies.handlers) == 0
+			if stop {
+				proxies.serving = false
+			}
+			proxies.Unlock()
+			if stop {
+				return
+			}
+			continue
+		}
+		go invoke(inv, id)
+	}
+}
+
+// invoke calls the Dispatch of the proxy a call is on, on a thread attached
+// to the JVM for the JNI calls it makes. The call fails if the Dispatch
+// returns an error or panics.
+func invoke(inv *Invocation, id int64) {
+	detach, err := attachThread()
+	if err != nil {
+		log.Printf("jagrt: proxy: %s", err)
+		return
+	}
+	defer detach()
+	defer func() {
+		if r := recover(); r != nil {
+			fail(inv, fmt.Sprintf("jagrt: proxy panic: %v", r))
+		}
+	}()
+	proxies.Lock()
+	dispatch := proxies.handlers[id]
+	proxies.Unlock()
+	if dispatch == nil {
+		fail(inv, "jagrt: proxy has been released")
+		return
+	}
+	if err := dispatch(inv); err != nil {
+		fail(inv, err.Error())
+	}
+}
+
+// fail fails a call, logging if that is not possible.
+func fail(inv *Invocation, message string) {
+	if err := inv.Fail(message); err != nil {
+		log.Printf("jagrt: proxy: %s: %s", message, err)
+	}
+}
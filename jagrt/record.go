@@ -0,0 +1,62 @@
+package jagrt
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/timob/javabind"
+)
+
+// ErrNoConstructor is returned converting a record to Java when its
+// canonical constructor was not generated.
+var ErrNoConstructor = errors.New("jagrt: record constructor not generated")
+
+// NewGoToJavaRecord returns the converter of Go record values to Java,
+// object makes the Java record for a value.
+func NewGoToJavaRecord(object func(value interface{}) (*javabind.Callable, error)) javabind.Converter {
+	return &goToJavaRecord{Converter: javabind.NewGoToJavaCallable(), object: object}
+}
+
+type goToJavaRecord struct {
+	javabind.Converter
+	object func(value interface{}) (*javabind.Callable, error)
+}
+
+func (c *goToJavaRecord) Convert(value interface{}) error {
+	obj, err := c.object(value)
+	if err != nil {
+		return err
+	}
+	return c.Converter.Convert(obj)
+}
+
+// NewJavaToGoRecord returns the converter of Java records to Go values,
+// value reads the components of a record.
+func NewJavaToGoRecord(value func(obj *javabind.Callable) (interface{}, error)) javabind.Converter {
+	return &javaToGoRecord{Converter: javabind.NewJavaToGoCallable(), value: value}
+}
+
+type javaToGoRecord struct {
+	javabind.Converter
+	value    func(obj *javabind.Callable) (interface{}, error)
+	dst      reflect.Value
+	callable *javabind.Callable
+}
+
+func (c *javaToGoRecord) Dest(dst interface{}) {
+	c.dst = reflect.ValueOf(dst).Elem()
+	c.callable = &javabind.Callable{}
+	c.Converter.Dest(c.callable)
+}
+
+func (c *javaToGoRecord) Convert(value interface{}) error {
+	if err := c.Converter.Convert(value); err != nil {
+		return err
+	}
+	v, err := c.value(c.callable)
+	if err != nil {
+		return err
+	}
+	c.dst.Set(reflect.ValueOf(v))
+	return nil
+}
@@ -0,0 +1,74 @@
+package jag
+
+import (
+	"archive/zip"
+	"strings"
+	"unicode"
+)
+
+// JarClasses returns the class file entries of a jar in packages starting
+// with prefix, eg "com.amazonaws.services.ec2". An empty prefix matches all.
+func JarClasses(r *zip.Reader, prefix string) (files []*zip.File) {
+	dir := strings.Replace(prefix, ".", "/", -1)
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".class") || !strings.HasPrefix(f.Name, dir) {
+			continue
+		}
+		// module-info.class and package-info.class are not classes
+		if strings.HasSuffix(f.Name, "-info.class") {
+			continue
+		}
+		files = append(files, f)
+	}
+	return
+}
+
+// goFileSuffixes are the last elements of file names which the go command
+// treats specially, as tests or build constraints on GOOS and GOARCH.
+var goFileSuffixes = map[string]bool{
+	"test": true,
+
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+	"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true,
+	"openbsd": true, "plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+	"mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true,
+	"riscv64": true, "s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
+
+// GoFileName returns the name of the Go file generated for a Java class,
+// eg local.SuperFoo with trim "local" gives super_foo.go. Names the go
+// command would not build as a normal file get _java added, so
+// com.acme.Windows gives com_acme_windows_java.go and local.FooTest gives
+// local_foo_test_java.go.
+func GoFileName(className, trim string) string {
+	if trim != "" {
+		className = strings.TrimPrefix(className, trim+".")
+	}
+	var z []rune
+	prev := '_'
+	for _, r := range className {
+		if r == '.' || r == '$' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) && prev != '_' && !unicode.IsUpper(prev) {
+			z = append(z, '_')
+		}
+		z = append(z, unicode.ToLower(r))
+		prev = r
+	}
+	name := string(z)
+	// files starting with _ are ignored
+	if strings.HasPrefix(name, "_") {
+		name = "java" + name
+	}
+	if goFileSuffixes[name[strings.LastIndex(name, "_")+1:]] {
+		name += "_java"
+	}
+	return name + ".go"
+}
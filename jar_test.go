@@ -0,0 +1,49 @@
+package jag
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestJarClasses(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, name := range []string{"META-INF/MANIFEST.MF", "local/Foo.class", "local/sub/Bar.class", "localx/Qux.class", "other/Baz.class", "local/package-info.class"} {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := JarClasses(r, "local")
+	if len(files) != 2 || files[0].Name != "local/Foo.class" || files[1].Name != "local/sub/Bar.class" {
+		t.Fatalf("bad jar classes %v", files)
+	}
+}
+
+func TestGoFileName(t *testing.T) {
+	if name := GoFileName("local.SuperFoo", "local"); name != "super_foo.go" {
+		t.Fatal(name)
+	}
+	if name := GoFileName("local.SuperFoo", ""); name != "local_super_foo.go" {
+		t.Fatal(name)
+	}
+	// the go command would take these as tests or for other platforms
+	for className, want := range map[string]string{
+		"com.acme.Windows":   "com_acme_windows_java.go",
+		"com.acme.Foo$Linux": "com_acme_foo_linux_java.go",
+		"local.Amd64":        "local_amd64_java.go",
+		"local.FooTest":      "local_foo_test_java.go",
+		"local.Testing":      "local_testing.go",
+		"_Foo":               "java_foo.go",
+	} {
+		if name := GoFileName(className, ""); name != want {
+			t.Errorf("%s: got %s, want %s", className, name, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package jag
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ReadJavadoc returns the Javadoc comments in Java source, keyed by the
+// declaration that follows each comment. Declarations end where statements
+// do, skipping braces in annotation arguments and initializers, and have
+// their whitespace and annotations removed the same way, so they match the
+// Line of the constructors, methods and fields parsed from the same source.
+func ReadJavadoc(r io.Reader) (docs map[string]string, err error) {
+	docs = make(map[string]string)
+	br := bufio.NewReader(r)
+
+	var doc string
+	var decl bytes.Buffer
+	var haveDoc bool
+	var scan statementScanner
+
+	next := func() (byte, bool) {
+		c, err2 := br.ReadByte()
+		if err2 != nil {
+			if err2 != io.EOF {
+				err = err2
+			}
+			return 0, false
+		}
+		return c, true
+	}
+	peek := func() byte {
+		b, _ := br.Peek(1)
+		if len(b) == 0 {
+			return 0
+		}
+		return b[0]
+	}
+
+	for {
+		c, ok := next()
+		if !ok {
+			return
+		}
+		switch {
+		case c == '/' && peek() == '/':
+			for c != '\n' && ok {
+				c, ok = next()
+			}
+			decl.WriteByte(' ')
+		case c == '/' && peek() == '*':
+			next()
+			var comment bytes.Buffer
+			prev := byte(0)
+			for {
+				c, ok = next()
+				if !ok {
+					return
+				}
+				if prev == '*' && c == '/' {
+					break
+				}
+				comment.WriteByte(c)
+				prev = c
+			}
+			body := strings.TrimSuffix(comment.String(), "*")
+			if strings.HasPrefix(body, "*") && body != "*" {
+				doc = body[1:]
+				haveDoc = true
+				decl.Reset()
+			} else {
+				decl.WriteByte(' ')
+			}
+		case c == '"' || c == '\'':
+			decl.WriteByte(c)
+			quote := c
+			for {
+				c, ok = next()
+				if !ok {
+					return
+				}
+				decl.WriteByte(c)
+				if c == '\\' {
+					if c, ok = next(); ok {
+						decl.WriteByte(c)
+					}
+				} else if c == quote {
+					break
+				}
+			}
+		case scan.next(c):
+			if haveDoc {
+				docs[stripAnnotations(joinFields(decl.Bytes()))] = doc
+				haveDoc = false
+			}
+			decl.Reset()
+		default:
+			decl.WriteByte(c)
+		}
+	}
+}
+
+var (
+	javadocInlineTagRe = regexp.MustCompile(`\{@(\w+)\s*([^}]*)\}`)
+	htmlTagRe          = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	htmlParagraphRe    = regexp.MustCompile(`(?i)<p\s*/?>|</p>|<br\s*/?>`)
+)
+
+var htmlEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#64;", "@", "&nbsp;", " ", "&amp;", "&")
+
+// javadocInline replaces inline tags and HTML in Javadoc text with plain text.
+func javadocInline(s string) string {
+	s = javadocInlineTagRe.ReplaceAllStringFunc(s, func(tag string) string {
+		m := javadocInlineTagRe.FindStringSubmatch(tag)
+		text := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "link", "linkplain":
+			// {@link Foo#bar(int, int) label}
+			ref, label := text, ""
+			if i := strings.Index(text, ")"); i >= 0 && strings.Contains(text[:i], "(") {
+				ref, label = text[:i+1], text[i+1:]
+			} else if i := strings.IndexAny(text, " \t"); i >= 0 {
+				ref, label = text[:i], text[i:]
+			}
+			if label = strings.TrimSpace(label); label != "" {
+				return label
+			}
+			return strings.Replace(strings.TrimPrefix(ref, "#"), "#", ".", -1)
+		}
+		return text
+	})
+	s = htmlParagraphRe.ReplaceAllString(s, "\n\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	return htmlEntities.Replace(s)
+}
+
+// FormatJavadoc turns the body of a Javadoc comment into Go doc text. Block
+// tags such as @param, @return and @throws become paragraphs after the
+// description.
+func FormatJavadoc(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	// split into description and block tags
+	var description string
+	var tags []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@") {
+			tags = append(tags, line)
+		} else if len(tags) > 0 {
+			if line != "" {
+				tags[len(tags)-1] += " " + line
+			}
+		} else {
+			description += line + "\n"
+		}
+	}
+
+	var params, paragraphs []string
+	for _, tag := range tags {
+		fields := strings.Fields(javadocInline(tag))
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[0]
+		fields = fields[1:]
+		switch name {
+		case "@param":
+			if len(fields) > 0 {
+				params = append(params, "  - "+strings.Trim(fields[0], "<>")+": "+strings.Join(fields[1:], " "))
+			}
+		case "@return":
+			paragraphs = append(paragraphs, "Returns "+strings.Join(fields, " "))
+		case "@throws", "@exception":
+			if len(fields) > 0 {
+				paragraphs = append(paragraphs, "Throws "+fields[0]+" "+strings.Join(fields[1:], " "))
+			}
+		case "@deprecated":
+			paragraphs = append(paragraphs, "Deprecated: "+strings.Join(fields, " "))
+		case "@see":
+			paragraphs = append(paragraphs, "See "+strings.Replace(strings.Join(fields, " "), "#", ".", -1))
+		}
+	}
+
+	var out []string
+	for _, paragraph := range strings.Split(javadocInline(description), "\n\n") {
+		var text []string
+		for _, line := range strings.Split(paragraph, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				text = append(text, line)
+			}
+		}
+		if len(text) > 0 {
+			out = append(out, strings.Join(text, "\n"))
+		}
+	}
+	if len(params) > 0 {
+		out = append(out, "Parameters:\n"+strings.Join(params, "\n"))
+	}
+	for _, p := range paragraphs {
+		out = append(out, strings.TrimSpace(p))
+	}
+	return strings.Join(out, "\n\n")
+}
@@ -0,0 +1,116 @@
+package jag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJavadoc(t *testing.T) {
+	docs, err := ReadJavadoc(strings.NewReader(`
+package local;
+
+/**
+ * A foo.
+ */
+public class Foo {
+    /** The answer. */
+    public static int answer = 42;
+
+    // not javadoc
+    /* not javadoc either */
+    public int size() { return 0; }
+
+    /**
+     * Reads the {@code path} using a {@link local.Bar#read(String) Bar}.
+     * <p>
+     * See {@link #size}.
+     *
+     * @param path the file
+     *   to read
+     * @return the contents
+     * @throws java.io.IOException if it can't be read
+     */
+    public String read(String path) throws java.io.IOException {
+        return "/** not a comment */";
+    }
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc := FormatJavadoc(docs["public class Foo"]); doc != "A foo." {
+		t.Fatalf("class doc %q", doc)
+	}
+	if doc := FormatJavadoc(docs["public static int answer = 42"]); doc != "The answer." {
+		t.Fatalf("field doc %q", doc)
+	}
+	if _, ok := docs["public int size()"]; ok {
+		t.Fatal("non javadoc comment captured")
+	}
+
+	want := `Reads the path using a Bar.
+
+See size.
+
+Parameters:
+  - path: the file to read
+
+Returns the contents
+
+Throws java.io.IOException if it can't be read`
+	if doc := FormatJavadoc(docs["public String read(String path) throws java.io.IOException"]); doc != want {
+		t.Fatalf("method doc %q", doc)
+	}
+}
+
+func TestJavadocDeclarationEnd(t *testing.T) {
+	src := `package local;
+public class Foo {
+    /** Sizes. */
+    @Sizes({1, 2})
+    public int size() { return 0; }
+    /** Values. */
+    public static final int[] VALUES = {1, 2};
+    /** Runner. */
+    public Runnable runner = new Runnable() { public void run() {} };
+    /** Count. */
+    public int count;
+}
+`
+	docs, err := ReadJavadoc(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for decl, want := range map[string]string{
+		"public int size()":                                                "Sizes.",
+		"public static final int[] VALUES = {1, 2}":                        "Values.",
+		"public Runnable runner = new Runnable() { public void run() {} }": "Runner.",
+		"public int count":                                                 "Count.",
+	} {
+		if doc := FormatJavadoc(docs[decl]); doc != want {
+			t.Errorf("%s: got %q, want %q", decl, doc, want)
+		}
+	}
+
+	// the keys are the lines the same declarations are parsed with, jagen
+	// drops the comments before parsing
+	var lines []string
+	for _, line := range strings.Split(src, "\n") {
+		if !strings.Contains(line, "/**") {
+			lines = append(lines, line)
+		}
+	}
+	sig, err := parseSource(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.Methods) != 1 || len(sig.Fields) != 3 {
+		t.Fatalf("%+v", sig)
+	}
+	for _, line := range []string{sig.Methods[0].Line, sig.Fields[0].Line, sig.Fields[1].Line, sig.Fields[2].Line} {
+		if _, ok := docs[line]; !ok {
+			t.Errorf("no doc for %q", line)
+		}
+	}
+}
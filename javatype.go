@@ -0,0 +1,253 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+type JavaTypeKind int
+
+const (
+	// boolean, int etc and void
+	PrimitiveType JavaTypeKind = iota
+	// a class, interface or type variable, with its type arguments
+	ClassType
+	// a type argument ?, ? extends Bound or ? super Bound
+	WildcardType
+)
+
+// JavaType is a parsed Java type as javap prints it, eg
+// "java.util.Map<java.lang.String, java.util.List<? extends local.Foo>>[]".
+type JavaType struct {
+	Kind JavaTypeKind
+	// primitive or class name, nested classes are written with $
+	Name string
+	Args []*JavaType
+	// array dimensions, the last is written as ... when Varargs is set
+	Dims    int
+	Varargs bool
+	// wildcard bound, nil for ?
+	Bound *JavaType
+	// Bound is a lower bound, ? super Bound
+	Lower bool
+}
+
+var primitiveTypes = map[string]bool{
+	"boolean": true, "byte": true, "char": true, "short": true,
+	"int": true, "long": true, "float": true, "double": true, "void": true,
+}
+
+// ParseJavaType parses a type written the way javap or Java source writes
+// it. Spaces are optional.
+func ParseJavaType(s string) (*JavaType, error) {
+	p := &javaTypeParser{s: s}
+	t, err := p.readType()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return t, nil
+}
+
+// parseJavaTypeName is ParseJavaType for types already checked by the
+// parser, anything it can't parse is taken as a class name.
+func parseJavaTypeName(s string) *JavaType {
+	t, err := ParseJavaType(s)
+	if err != nil {
+		return &JavaType{Kind: ClassType, Name: s}
+	}
+	return t
+}
+
+func isJavaNameByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type javaTypeParser struct {
+	s   string
+	pos int
+}
+
+func (p *javaTypeParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *javaTypeParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *javaTypeParser) readType() (*JavaType, error) {
+	if p.consume("?") {
+		t := &JavaType{Kind: WildcardType}
+		if p.consume("extends") {
+		} else if p.consume("super") {
+			t.Lower = true
+		} else {
+			return t, nil
+		}
+		bound, err := p.readType()
+		t.Bound = bound
+		return t, err
+	}
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && isJavaNameByte(p.s[p.pos]) && !strings.HasPrefix(p.s[p.pos:], "...") {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected type name")
+	}
+	t := &JavaType{Kind: ClassType, Name: p.s[start:p.pos]}
+	if primitiveTypes[t.Name] {
+		t.Kind = PrimitiveType
+	}
+
+	if t.Kind == ClassType && p.consume("<") {
+		for {
+			arg, err := p.readType()
+			if err != nil {
+				return nil, err
+			}
+			t.Args = append(t.Args, arg)
+			if p.consume(">") {
+				break
+			}
+			if !p.consume(",") {
+				return nil, p.errorf("expected , or >")
+			}
+		}
+	}
+
+	for {
+		if p.consume("[]") {
+			t.Dims++
+		} else if p.consume("...") {
+			t.Dims++
+			t.Varargs = true
+			break
+		} else {
+			break
+		}
+	}
+	return t, nil
+}
+
+func (p *javaTypeParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("bad type %q at %d: %s", p.s, p.pos, fmt.Sprintf(format, a...))
+}
+
+// String writes the type the way javap does.
+func (t *JavaType) String() string {
+	if t.Kind == WildcardType {
+		if t.Bound == nil {
+			return "?"
+		} else if t.Lower {
+			return "? super " + t.Bound.String()
+		}
+		return "? extends " + t.Bound.String()
+	}
+	z := t.Name
+	if len(t.Args) > 0 {
+		args := make([]string, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = arg.String()
+		}
+		z += "<" + strings.Join(args, ", ") + ">"
+	}
+	for i := 0; i < t.Dims; i++ {
+		if i == t.Dims-1 && t.Varargs {
+			z += "..."
+		} else {
+			z += "[]"
+		}
+	}
+	return z
+}
+
+// Elem returns the element type of an array, with one dimension less.
+func (t *JavaType) Elem() *JavaType {
+	e := *t
+	e.Dims--
+	e.Varargs = false
+	return &e
+}
+
+// IsArray reports whether t is an array, including varargs.
+func (t *JavaType) IsArray() bool {
+	return t.Dims > 0
+}
+
+// ClassNames returns the names of the classes t refers to, including those
+// in type arguments and wildcard bounds.
+func (t *JavaType) ClassNames() (names []string) {
+	switch t.Kind {
+	case WildcardType:
+		if t.Bound != nil {
+			names = t.Bound.ClassNames()
+		}
+	case ClassType:
+		names = append(names, t.Name)
+		for _, arg := range t.Args {
+			names = append(names, arg.ClassNames()...)
+		}
+	}
+	return
+}
+
+// split returns the outermost part of t and the types it is made from:
+// "[]" or "..." and the element type for arrays, otherwise the name and the
+// type arguments.
+func (t *JavaType) split() (head string, parts []*JavaType) {
+	if t.IsArray() {
+		if t.Varargs {
+			return "...", []*JavaType{t.Elem()}
+		}
+		return "[]", []*JavaType{t.Elem()}
+	}
+	if t.Kind == WildcardType {
+		return t.String(), nil
+	}
+	return t.Name, t.Args
+}
+
+// Components is split with the parts written without spaces.
+func (t *JavaType) Components() []string {
+	head, parts := t.split()
+	p := []string{strings.Replace(head, " ", "", -1)}
+	for _, part := range parts {
+		p = append(p, strings.Replace(part.String(), " ", "", -1))
+	}
+	return p
+}
+
+// WildcardBound returns the type values of a wildcard can be used as, Bound
+// for ? extends Bound and java.lang.Object for others. Other types are
+// returned as is.
+func (t *JavaType) WildcardBound() *JavaType {
+	if t.Kind != WildcardType {
+		return t
+	}
+	if t.Bound != nil && !t.Lower {
+		return t.Bound
+	}
+	return &JavaType{Kind: ClassType, Name: "java.lang.Object"}
+}
+
+// Raw returns t without its type arguments, and varargs as an array, the
+// way reflection names a parameter type.
+func (t *JavaType) Raw() *JavaType {
+	r := *t
+	r.Args = nil
+	r.Varargs = false
+	return &r
+}
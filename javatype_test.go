@@ -0,0 +1,65 @@
+package jag
+
+import (
+	"testing"
+)
+
+func TestJavaTypeRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"int",
+		"int[][]",
+		"java.lang.String...",
+		"java.util.Map$Entry<java.lang.String, int[]>",
+		"java.util.Map<java.lang.String, java.util.List<? extends local.Foo>>[]",
+		"java.util.List<? super T>",
+		"java.lang.Class<?>",
+	} {
+		jt, err := ParseJavaType(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jt.String() != s {
+			t.Errorf("got %q, want %q", jt.String(), s)
+		}
+	}
+
+	jt, err := ParseJavaType("java.util.Map<String,java.util.List<?extends Foo>>[][]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jt.Kind != ClassType || jt.Name != "java.util.Map" || jt.Dims != 2 || len(jt.Args) != 2 {
+		t.Fatalf("%+v", jt)
+	}
+	if w := jt.Args[1].Args[0]; w.Kind != WildcardType || w.Lower || w.Bound.Name != "Foo" {
+		t.Fatalf("%+v", w)
+	}
+	if jt.String() != "java.util.Map<String, java.util.List<? extends Foo>>[][]" {
+		t.Fatal(jt.String())
+	}
+	if names := jt.ClassNames(); len(names) != 4 || names[3] != "Foo" {
+		t.Fatal(names)
+	}
+	if c := jt.Components(); len(c) != 2 || c[0] != "[]" || c[1] != "java.util.Map<String,java.util.List<?extendsFoo>>[]" {
+		t.Fatal(c)
+	}
+
+	for _, s := range []string{"", "java.util.List<", "List<String>>", "int[", "@Nullable String"} {
+		if _, err := ParseJavaType(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestClassSigFilter(t *testing.T) {
+	sig := &ClassSig{Methods: []*ClassSigMethod{
+		{Name: "a", Return: "java.util.List<java.util.Map<java.lang.String, local.Bad>>"},
+		{Name: "b", Return: "local.Bad[]"},
+		{Name: "c", Return: "java.util.List<java.lang.String>"},
+	}}
+	handle := &ParserHandle{}
+	NewParser(handle, nil, nil, sig, nil, nil)
+	methods := NewClassSigFilter(handle.Parser, "local.Bad").GetMethods()
+	if len(methods) != 1 || methods[0].Name != "c" {
+		t.Fatalf("%+v", methods)
+	}
+}
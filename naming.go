@@ -0,0 +1,240 @@
+package jag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// OverloadNamer picks Go names for constructors and methods. Java allows
+// overloading so names have to be made unique.
+//
+// ConstructorNames returns complete function names. MethodNames returns
+// method names, which for static methods are prefixed with the Go class name
+// by the generator.
+type OverloadNamer interface {
+	ConstructorNames(className, goClassName string, constructors []*ClassSigConstructor) []string
+	MethodNames(className string, methods []*ClassSigMethod) []string
+}
+
+// NewOverloadNamer returns the namer for a strategy name: "index", "types"
+// or "names".
+func NewOverloadNamer(strategy string) (OverloadNamer, error) {
+	switch strategy {
+	case "", "index":
+		return IndexNaming{}, nil
+	case "types":
+		return TypeNaming{}, nil
+	case "names":
+		return ParamNameNaming{}, nil
+	}
+	return nil, fmt.Errorf("unknown overload naming strategy %q", strategy)
+}
+
+// IndexNaming numbers overloads in declaration order, eg Read, Read2, Read3.
+type IndexNaming struct{}
+
+func (IndexNaming) ConstructorNames(className, goClassName string, constructors []*ClassSigConstructor) (names []string) {
+	for i := range constructors {
+		name := "New" + goClassName
+		if i > 0 {
+			name += fmt.Sprintf("%d", i+1)
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+func (IndexNaming) MethodNames(className string, methods []*ClassSigMethod) (names []string) {
+	methodCount := make(map[string]int)
+	for _, method := range methods {
+		name := capitalize(method.Name)
+		if v, ok := methodCount[method.Name]; ok {
+			v++
+			name += fmt.Sprintf("%d", v)
+			methodCount[method.Name] = v
+		} else {
+			methodCount[method.Name] = 1
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+// TypeNaming suffixes constructors and methods with their parameter types,
+// eg ReadString, ReadInt. A constructor or method without parameters keeps
+// the plain name. Every one is suffixed, overloaded or not, so adding an
+// overload in Java doesn't rename the existing ones.
+type TypeNaming struct{}
+
+func (TypeNaming) ConstructorNames(className, goClassName string, constructors []*ClassSigConstructor) []string {
+	return suffixConstructors(goClassName, constructors, typeSuffix)
+}
+
+func (TypeNaming) MethodNames(className string, methods []*ClassSigMethod) []string {
+	return suffixMethods(methods, typeSuffix)
+}
+
+// ParamNameNaming suffixes constructors and methods with their parameter
+// names, eg ReadPath, ReadPathOffset, overloaded or not like TypeNaming. The
+// names come from the Java source so -src should be used, ones without them
+// are named like TypeNaming.
+type ParamNameNaming struct{}
+
+func (ParamNameNaming) ConstructorNames(className, goClassName string, constructors []*ClassSigConstructor) []string {
+	return suffixConstructors(goClassName, constructors, paramNameSuffix)
+}
+
+func (ParamNameNaming) MethodNames(className string, methods []*ClassSigMethod) []string {
+	return suffixMethods(methods, paramNameSuffix)
+}
+
+func typeSuffix(params Params) (z string) {
+	for _, p := range params {
+		z += typeSuffixName(p.Type)
+	}
+	return
+}
+
+func typeSuffixName(t string) string {
+	jc := JavaTypeComponents(t)
+	if jc[0] == "[]" || jc[0] == "..." {
+		return typeSuffixName(jc[1]) + "Array"
+	}
+	return capitalize(strings.Replace(className(jc[0]), "$", "", -1))
+}
+
+func paramNameSuffix(params Params) (z string) {
+	generated := true
+	for i, p := range params {
+		if p.Name != fmt.Sprintf("%c", 'a'+i) {
+			generated = false
+		}
+		z += capitalize(p.Name)
+	}
+	if generated {
+		return typeSuffix(params)
+	}
+	return
+}
+
+func signature(name string, params Params) string {
+	return name + "(" + strings.Replace(strings.Join(params.Types(), ","), " ", "", -1) + ")"
+}
+
+func suffixConstructors(goClassName string, constructors []*ClassSigConstructor, suffix func(Params) string) []string {
+	names := make([]string, len(constructors))
+	signatures := make([]string, len(constructors))
+	for i, c := range constructors {
+		names[i] = "New" + goClassName + suffix(c.Params)
+		signatures[i] = signature("", c.Params)
+	}
+	return uniqueNames(names, signatures)
+}
+
+func suffixMethods(methods []*ClassSigMethod, suffix func(Params) string) []string {
+	names := make([]string, len(methods))
+	signatures := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = capitalize(m.Name) + suffix(m.Params)
+		signatures[i] = signature(m.Name, m.Params)
+	}
+	return uniqueNames(names, signatures)
+}
+
+// uniqueNames numbers duplicate names 2, 3... in order of their signatures,
+// so the result does not depend on declaration order.
+func uniqueNames(names, signatures []string) []string {
+	order := make([]int, len(names))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return signatures[order[i]] < signatures[order[j]]
+	})
+
+	used := make(map[string]bool)
+	for _, name := range names {
+		used[name] = true
+	}
+	seen := make(map[string]int)
+	z := make([]string, len(names))
+	for _, i := range order {
+		name := names[i]
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			for used[fmt.Sprintf("%s%d", names[i], n)] {
+				n++
+			}
+			seen[name] = n
+			name = fmt.Sprintf("%s%d", names[i], n)
+			used[name] = true
+		}
+		z[i] = name
+	}
+	return z
+}
+
+// RenameMap gives explicit Go names to constructors and methods, other
+// names come from Fallback. Each line of a rename file is a Java signature,
+// optionally qualified with the class name, and the Go name:
+//
+//	local.Foo.read(java.lang.String) ReadPath
+//	read(int,int) ReadRange
+//	local.Foo(boolean) NewFooChecked
+//
+// Constructors are written with the class name, qualified or not.
+type RenameMap struct {
+	names    map[string]string
+	Fallback OverloadNamer
+}
+
+func NewRenameMap(r io.Reader, fallback OverloadNamer) (*RenameMap, error) {
+	m := &RenameMap{make(map[string]string), fallback}
+	lineScanner := bufio.NewScanner(r)
+	for line := 1; lineScanner.Scan(); line++ {
+		text := strings.TrimSpace(lineScanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		end := strings.LastIndex(text, ")")
+		if end < 0 || strings.TrimSpace(text[end+1:]) == "" {
+			return nil, fmt.Errorf("rename map line %d: expected signature and Go name", line)
+		}
+		m.names[strings.Replace(text[:end+1], " ", "", -1)] = strings.TrimSpace(text[end+1:])
+	}
+	return m, lineScanner.Err()
+}
+
+func (m *RenameMap) lookup(className, sig string) (string, bool) {
+	if name, ok := m.names[className+"."+sig]; ok {
+		return name, true
+	}
+	name, ok := m.names[sig]
+	return name, ok
+}
+
+func (m *RenameMap) ConstructorNames(className, goClassName string, constructors []*ClassSigConstructor) []string {
+	names := m.Fallback.ConstructorNames(className, goClassName, constructors)
+	simpleName := className[strings.LastIndex(className, ".")+1:]
+	for i, c := range constructors {
+		if name, ok := m.names[signature(className, c.Params)]; ok {
+			names[i] = name
+		} else if name, ok := m.names[signature(simpleName, c.Params)]; ok {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+func (m *RenameMap) MethodNames(className string, methods []*ClassSigMethod) []string {
+	names := m.Fallback.MethodNames(className, methods)
+	for i, method := range methods {
+		if name, ok := m.lookup(className, signature(method.Name, method.Params)); ok {
+			names[i] = name
+		}
+	}
+	return names
+}
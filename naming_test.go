@@ -0,0 +1,108 @@
+package jag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOverloadNaming(t *testing.T) {
+	methods := []*ClassSigMethod{
+		{Name: "read", Params: Params{{"a", "java.lang.String"}}},
+		{Name: "read", Params: Params{{"a", "int"}}},
+		{Name: "read"},
+		{Name: "read", Params: Params{{"a", "java.util.List<java.lang.String>"}}},
+		{Name: "read", Params: Params{{"a", "java.util.List<java.lang.Integer>"}}},
+		{Name: "close"},
+	}
+	names := TypeNaming{}.MethodNames("local.Foo", methods)
+	want := []string{"ReadString", "ReadInt", "Read", "ReadList2", "ReadList", "Close"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("got %v want %v", names, want)
+	}
+
+	// reordering the Java declarations must not change the Go names
+	reversed := make([]*ClassSigMethod, len(methods))
+	for i, m := range methods {
+		reversed[len(methods)-1-i] = m
+	}
+	names = TypeNaming{}.MethodNames("local.Foo", reversed)
+	for i := range names {
+		if names[i] != want[len(want)-1-i] {
+			t.Fatalf("got %v for reversed declarations", names)
+		}
+	}
+
+	// adding an overload must not rename the existing methods
+	names = TypeNaming{}.MethodNames("local.Foo", []*ClassSigMethod{methods[0], methods[5]})
+	if strings.Join(names, " ") != "ReadString Close" {
+		t.Fatalf("got %v before adding overloads", names)
+	}
+	constructors := []*ClassSigConstructor{{Params: Params{{"a", "java.lang.String"}}}}
+	if names := (TypeNaming{}).ConstructorNames("local.Foo", "LocalFoo", constructors); names[0] != "NewLocalFooString" {
+		t.Fatalf("got %v", names)
+	}
+	constructors = append(constructors, &ClassSigConstructor{})
+	if names := (TypeNaming{}).ConstructorNames("local.Foo", "LocalFoo", constructors); names[0] != "NewLocalFooString" || names[1] != "NewLocalFoo" {
+		t.Fatalf("got %v after adding a constructor", names)
+	}
+
+	methods[0].Params[0].Name = "path"
+	if names := (ParamNameNaming{}).MethodNames("local.Foo", methods[:3]); names[0] != "ReadPath" || names[1] != "ReadInt" {
+		t.Fatalf("got %v", names)
+	}
+}
+
+func TestRenameMap(t *testing.T) {
+	m, err := NewRenameMap(strings.NewReader(`
+# comment
+local.Foo.read(java.lang.String) ReadPath
+local.Foo(boolean) NewFooChecked
+`), IndexNaming{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := []*ClassSigMethod{
+		{Name: "read", Params: Params{{"a", "int"}}},
+		{Name: "read", Params: Params{{"a", "java.lang.String"}}},
+	}
+	if names := m.MethodNames("local.Foo", methods); names[0] != "Read" || names[1] != "ReadPath" {
+		t.Fatalf("got %v", names)
+	}
+
+	constructors := []*ClassSigConstructor{{}, {Params: Params{{"a", "boolean"}}}}
+	if names := m.ConstructorNames("local.Foo", "LocalFoo", constructors); names[0] != "NewLocalFoo" || names[1] != "NewFooChecked" {
+		t.Fatalf("got %v", names)
+	}
+}
+
+func TestNameRules(t *testing.T) {
+	rules, err := LoadNameRules(strings.NewReader(`
+alias com.amazonaws.services.ec2.model Ec2
+alias com.amazonaws.services Aws
+regex ^org\.example\.internal\. Internal.
+strip-suffix Impl
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	translator := NewTranslator(nil, "")
+	translator.Names = rules
+	for java, want := range map[string]string{
+		"com.amazonaws.services.ec2.model.Instance": "Ec2Instance",
+		"com.amazonaws.services.s3.Client":          "AwsS3Client",
+		"org.example.internal.Widget":               "InternalWidget",
+		"org.example.WidgetImpl":                    "OrgExampleWidget",
+		"org.example.Widget":                        "OrgExampleWidget",
+	} {
+		if got := translator.javaNameToGoName(java); got != want {
+			t.Fatalf("%s: got %s want %s", java, got, want)
+		}
+	}
+
+	collisions := rules.Collisions()
+	if len(collisions) != 1 || collisions[0] != "OrgExampleWidget: org.example.Widget, org.example.WidgetImpl" {
+		t.Fatalf("collisions %v", collisions)
+	}
+}
@@ -0,0 +1,55 @@
+package jag
+
+import (
+	"strings"
+)
+
+// IsAnonymousClass reports whether a class name is that of an anonymous or
+// local class, eg local.Foo$1 or local.Foo$1Bar, which can't be used outside
+// the code declaring them.
+func IsAnonymousClass(name string) bool {
+	parts := strings.Split(name, "$")
+	for _, part := range parts[1:] {
+		if part == "" || part[0] >= '0' && part[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingClass returns the class a nested class is declared in, eg
+// local.Outer for local.Outer$Inner, or "" if name is not nested.
+func enclosingClass(name string) string {
+	if i := strings.LastIndex(name, "$"); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// checkInner records the enclosing class of an inner class, whose instance
+// is the first parameter of its constructors, and names that parameter
+// outer. javap doesn't say whether a nested class is static, so a class is
+// taken to be inner when all its constructors start with the enclosing
+// class.
+func (c *ClassSig) checkInner() {
+	outer := enclosingClass(c.ClassName)
+	if outer == "" || c.Kind != Class && c.Kind != AbstractClass || len(c.Constructors) == 0 {
+		return
+	}
+	for _, constructor := range c.Constructors {
+		if len(constructor.Params) == 0 || parseJavaTypeName(constructor.Params[0].Type).Name != outer {
+			return
+		}
+	}
+	c.setOuter(outer)
+}
+
+// setOuter records outer as the enclosing class of an inner class.
+func (c *ClassSig) setOuter(outer string) {
+	c.Outer = outer
+	for _, constructor := range c.Constructors {
+		if len(constructor.Params) > 0 {
+			constructor.Params[0].Name = "outer"
+		}
+	}
+}
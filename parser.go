@@ -8,7 +8,9 @@ import (
 	"bytes"
 	"log"
 	"github.com/timob/sliceutil"
+	"github.com/timob/commentfilter"
 	"regexp"
+	"errors"
 )
 
 var debug = false
@@ -18,85 +20,67 @@ func capitalize(s string) string {
 }
 
 type Parser interface {
-	GetStatement() string
-	ParseStatement()
+	GetStatement() (string, error)
+	ParseStatement() error
 	GetToken(int) string
 	ScopeDepth() int
+	Position() (line, column int)
 	GetCurrentStatement() string
 	FindToken(token string) (pos int, found bool)
-	Scan()
+	Scan() error
 	io.Reader
 	ParamParser
 	ClassSigInterface
 }
 
 type ClassSigInterface interface {
-	ParamWords() (count int, start int)
-	Parse()
+	ParamWords() (count int, start int, err error)
+	Parse() error
 	GetPackageName() string
 	GetClassName() string
+	GetKind() ClassKind
+	GetTypeParams() TypeParams
     GetExtends() string
+	GetInterfaces() []string
+	GetDoc() string
 	GetFields() []*ClassSigField
+	GetComponents() []*ClassSigField
 	GetConstructors() []*ClassSigConstructor
 	GetMethods() []*ClassSigMethod
 	GetClassSignature() ClassSigInterface
 }
 
 type ParamParser interface {
-	GetParams() Params
+	GetParams() (Params, error)
 }
 
-type ParserHandle struct {
-		Parser
+// ParseError is a problem found in the parser input, at the line and column
+// where the statement starts.
+type ParseError struct {
+	Line int
+	Column int
+	Statement string
+	Err error
 }
 
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d column %d: %s: %q", e.Line, e.Column, e.Err, e.Statement)
+}
 
-func JavaTypeComponents(j string) (p []string) {
-	if strings.HasSuffix(j, "...") {
-		return []string{"...", strings.TrimSuffix(string(j), "...")}
-	} else if strings.HasSuffix(j, "[]") {
-		return []string{"[]", strings.TrimSuffix(string(j), "[]")}
-	}
-
-	s := bufio.NewScanner(bytes.NewBufferString(strings.Replace(j, " ", "", -1)))
-	var scopeDepth int
-	s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		if atEOF && len(data) == 0 {
-            return 0, nil, nil
-        }
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
 
-        if i := bytes.IndexAny(data, "<>,"); i >= 0 {
-			if string(data[i]) == "<" {
-				scopeDepth++
-			}
+var ErrUnexpectedEOF = errors.New("unexpected end of input")
 
-			var ret []byte
-			if scopeDepth < 2 {
-				ret = data[0:i]
-			} else {
-				ret = data[0:i+1]
-			}
+type ParserHandle struct {
+		Parser
+}
 
-			if string(data[i]) == ">" {
-				scopeDepth--
-			}
-			return i + 1, ret, nil
-		} else if atEOF {
-			return len(data), data, nil
-		}
-		return 0, nil, nil
-	})
 
-	p = make([]string, 0)
-	var part string
-	for s.Scan() {
-		part += s.Text()
-		if scopeDepth < 2 && part != "" {
-			p = append(p, part)
-			part = ""
-		}
-	}
-	return
+// JavaTypeComponents is JavaType.Components for a type written as a string.
+func JavaTypeComponents(j string) (p []string) {
+	return parseJavaTypeName(j).Components()
 }
 
 type Param struct {
@@ -136,71 +120,291 @@ func (params Params) TypeClassNames() (names []string) {
 	return
 }
 
-type stmtMsg struct {
-	statement string
-	depth int
-}
-
+// Statements splits the input into statements for Parse, ending at ; { or }.
+// Statements are read on demand by GetStatement so Scan runs Parse to
+// completion on the calling goroutine.
 type Statements struct {
-	stmts chan *stmtMsg
+	scanner *bufio.Scanner
 	scopeDepth int
+	line int
+	column int
 	Parser Parser
 }
 
 func NewStatements(g Parser) (a *Statements) {
-	a = &Statements{make(chan *stmtMsg, 0), 0, g}
+	a = &Statements{Parser: g}
 	return
 }
 
-func (s *Statements) GetStatement() string {
-	x := <- s.stmts
-	s.scopeDepth = x.depth
-	return x.statement
+// GetStatement returns the next statement, or io.EOF after the last one.
+func (s *Statements) GetStatement() (string, error) {
+	if s.scanner == nil {
+		s.scanner = s.newScanner()
+	}
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return stripAnnotations(joinFields(s.scanner.Bytes())), nil
 }
 
-func (s *Statements) Scan() {
-	go s.Parser.Parse()
+// joinFields returns b with each run of white space outside string and char
+// literals replaced by one space, and none at the ends.
+func joinFields(b []byte) string {
+	var z []byte
+	var quote byte
+	space := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if quote == 0 && (c == ' ' || c == '\t' || c == '\r' || c == '\n') {
+			space = len(z) > 0
+			continue
+		}
+		if space {
+			z = append(z, ' ')
+			space = false
+		}
+		z = append(z, c)
+		if quote != 0 && c == '\\' && i+1 < len(b) {
+			i++
+			z = append(z, b[i])
+		} else if c == quote {
+			quote = 0
+		} else if quote == 0 && (c == '"' || c == '\'') {
+			quote = c
+		}
+	}
+	return string(z)
+}
+
+// stripAnnotations returns a statement without its annotations and their
+// arguments, eg "@Deprecated public void put(@NonNull java.lang.String)"
+// becomes "public void put(java.lang.String)". Statements are joined by
+// joinFields first.
+func stripAnnotations(stmt string) string {
+	var z []byte
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		if quote != 0 {
+			z = append(z, c)
+			if c == '\\' && i+1 < len(stmt) {
+				i++
+				z = append(z, stmt[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+		}
+		if c != '@' || strings.HasPrefix(stmt[i:], "@interface") && !isJavaIdentByte(stmt, i+len("@interface")) {
+			z = append(z, c)
+			continue
+		}
+		j := i + 1
+		for isJavaIdentByte(stmt, j) || j < len(stmt) && stmt[j] == '.' {
+			j++
+		}
+		k := j
+		if k < len(stmt) && stmt[k] == ' ' {
+			k++
+		}
+		if k < len(stmt) && stmt[k] == '(' {
+			j = closingParen(stmt, k) + 1
+		}
+		if j < len(stmt) && stmt[j] == ' ' {
+			j++
+		}
+		i = j - 1
+	}
+	return strings.TrimRight(string(z), " ")
+}
+
+// isJavaIdentByte reports whether s[i] can be part of a Java identifier.
+func isJavaIdentByte(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
 
+// closingParen returns the index of the ) closing the ( at s[open], or the
+// last index of s if it is not closed.
+func closingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+// statementScanner finds the end of a statement in the bytes given to next,
+// which are outside of comments and literals: a ; { or }, but not a { or }
+// in parentheses, like the arguments of an annotation, or any in the braces
+// of an initializer, which ends at the ; after it.
+type statementScanner struct {
+	parens      int
+	initializer bool
+	braces      int
+}
+
+// next reports whether c ends the statement, and resets s if it does.
+func (s *statementScanner) next(c byte) bool {
+	switch {
+	case c == '(':
+		s.parens++
+	case c == ')':
+		if s.parens > 0 {
+			s.parens--
+		}
+	case s.parens > 0 && c != ';':
+	case c == '=':
+		s.initializer = true
+	case s.initializer && c == '{':
+		s.braces++
+	case s.initializer && c == '}' && s.braces > 0:
+		s.braces--
+	case s.initializer && s.braces > 0:
+	case c == ';' || c == '{' || c == '}':
+		*s = statementScanner{}
+		return true
+	}
+	return false
+}
+
+// statementEnd returns the index of the ; { or } ending the first statement
+// in data, skipping string and char literals, or -1 if there is none.
+func statementEnd(data []byte) int {
+	var quote byte
+	var scan statementScanner
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case scan.next(c):
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Statements) newScanner() *bufio.Scanner {
 	scanner := bufio.NewScanner(s.Parser)
 
 	depth := 0
+	line, column := 1, 1
+	advancePosition := func(data []byte) {
+		for _, b := range data {
+			if b == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+	}
 	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		if i := bytes.IndexAny(data, ";{}"); i >= 0 {
+		if i := statementEnd(data); i >= 0 {
 			if string(data[i]) == "{" {
 				depth++
 			} else if string(data[i]) == "}" {
 				depth--
 			}
+			start := len(data[0:i]) - len(bytes.TrimLeft(data[0:i], " \t\r\n"))
+			advancePosition(data[0:start])
+			s.line, s.column = line, column
+			s.scopeDepth = depth
+			advancePosition(data[start:i+1])
 			return i + 1, data[0:i], nil
 		} else if atEOF {
 			return len(data), nil, nil
 		}
 		return 0, nil, nil
 	})
-	
-	for scanner.Scan() {
-		stmt := string(bytes.Join(bytes.Fields(scanner.Bytes()), []byte{' '}))
-		s.stmts <- &stmtMsg{stmt, depth}
-	}
+	return scanner
+}
+
+// Scan runs Parse over the input.
+func (s *Statements) Scan() error {
+	s.scanner = s.newScanner()
+	s.scopeDepth = 0
+	return s.Parser.Parse()
 }
 
 func (s *Statements) ScopeDepth() int {
 	return s.scopeDepth
 }
 
+func (s *Statements) Position() (line, column int) {
+	return s.line, s.column
+}
+
 type Tokens struct {
 	tokens []string
 	Parser Parser
 	currentStmt string
 }
 
-func (t *Tokens) ParseStatement() {
+func (t *Tokens) ParseStatement() (err error) {
 	tokens := make([]string, 0)
 
 	token := ""
 	depth := 0
-	t.currentStmt = t.Parser.GetStatement()
+	// string and char literals are kept whole
+	var quote rune
+	escaped := false
+	t.currentStmt, err = t.Parser.GetStatement()
+	if err != nil {
+		t.tokens = nil
+		return
+	}
 	for _, r := range t.currentStmt {
+		if quote != 0 {
+			token += string(r)
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		if r == '"' || r == '\'' {
+			quote = r
+			token += string(r)
+			continue
+		}
 		if r == '<' {
 			depth++
 		}
@@ -248,6 +452,7 @@ func (t *Tokens) ParseStatement() {
 	}
 
 	t.tokens = tokens
+	return
 }
 
 func (t *Tokens) GetToken(i int) string {
@@ -274,7 +479,12 @@ func (t *Tokens) GetCurrentStatement() string {
 type ClassSigConstructor struct {
 	Params Params
 	Throws bool
+	// the exception classes in the throws clause
+	Exceptions []string
 	Line string
+	Doc string
+	// type parameters of a generic constructor, Params are erased
+	TypeParams TypeParams
 }
 
 type ClassSigMethod struct {
@@ -282,23 +492,96 @@ type ClassSigMethod struct {
     Params Params
 	Return string
 	Throws bool
+	// the exception classes in the throws clause
+	Exceptions []string
 	Line string
 	Static bool
+	Doc string
+	// type parameters of a generic method, Params and Return are erased
+	TypeParams TypeParams
 }
 
 type ClassSigField struct {
 	Name string
 	Type string
 	Static bool
+	Final bool
+	// the constant value of a static final field as a Java literal, eg 42
+	// or "text", empty if it has none
+	Value string
+	// set for the constants of an enum. javap doesn't mark them, so there
+	// every static final field of an enum's own type is taken to be one.
+	Enum bool
+	Line string
+	Doc string
+}
+
+type ClassKind int
+
+const (
+	Class ClassKind = iota
+	AbstractClass
+	Interface
+	Enum
+	Annotation
+	Record
+)
+
+var classKindNames = []string{"class", "abstract class", "interface", "enum", "annotation", "record"}
+
+func (k ClassKind) String() string {
+	return classKindNames[k]
+}
+
+// IsAbstract reports whether classes of this kind can't be instantiated, so
+// objects of the type are instances of a subclass or implementation.
+func (k ClassKind) IsAbstract() bool {
+	return k == AbstractClass || k == Interface || k == Annotation
+}
+
+// classKind works out the kind of class from its declaration. javap writes
+// enums, records and annotations as the classes and interfaces they compile
+// to, so their superclass or superinterface is checked too.
+func classKind(keyword string, abstract bool, extends string) ClassKind {
+	extends = parseJavaTypeName(extends).Name
+	switch {
+	case keyword == "enum" || keyword == "class" && extends == "java.lang.Enum":
+		return Enum
+	case keyword == "record" || keyword == "class" && extends == "java.lang.Record":
+		return Record
+	case keyword == "@interface" || keyword == "interface" && extends == "java.lang.annotation.Annotation":
+		return Annotation
+	case keyword == "interface":
+		return Interface
+	case abstract:
+		return AbstractClass
+	}
+	return Class
 }
 
 type ClassSig struct {
 	PackageName string
 	ClassName string
+	Kind ClassKind
+	// type parameters of a generic class
+	TypeParams TypeParams
     Extends string
+	// the enclosing class of an inner class, an instance of it is the first
+	// parameter of the constructors
+	Outer string
+	// the interfaces a class implements, or an interface extends
+	Interfaces []string
+	// the class declaration and its Javadoc
+	Line string
+	Doc string
 	Constructors []*ClassSigConstructor
 	Methods []*ClassSigMethod
 	Fields []*ClassSigField
+	// the components of a record, in declaration order
+	Components []*ClassSigField
+	// declarations found but not bound: members with types that don't
+	// parse, and nested classes, which are bound on their own
+	Skipped []string
 	Parser Parser
 }
 
@@ -314,14 +597,43 @@ func (c *ClassSig) GetClassName() string {
 	return c.ClassName
 }
 
+func (c *ClassSig) GetKind() ClassKind {
+	return c.Kind
+}
+
+func (c *ClassSig) GetTypeParams() TypeParams {
+	return c.TypeParams
+}
+
 func (c *ClassSig) GetExtends() string {
     return c.Extends
 }
 
-func (c *ClassSig) Parse() {
+func (c *ClassSig) GetInterfaces() []string {
+	return c.Interfaces
+}
+
+func (c *ClassSig) GetDoc() string {
+	return c.Doc
+}
+
+// errorf returns a ParseError for the current statement.
+func (c *ClassSig) errorf(format string, a ...interface{}) error {
+	line, column := c.Parser.Position()
+	return &ParseError{line, column, c.Parser.GetCurrentStatement(), fmt.Errorf(format, a...)}
+}
+
+// Parse reads statements until the end of input.
+func (c *ClassSig) Parse() error {
 	for {
-		c.Parser.ParseStatement()
-		
+		if err := c.Parser.ParseStatement(); err == io.EOF {
+			c.checkRecord()
+			c.checkInner()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
 		if c.Parser.GetToken(0) == "package" {
 			c.PackageName = c.Parser.GetToken(1)
 		}
@@ -331,29 +643,77 @@ func (c *ClassSig) Parse() {
 			continue
 		}
 
-		declarePos, found := c.Parser.FindToken("class")
-		if !found {
-			declarePos, found = c.Parser.FindToken("interface")
-		}
-		if !found {
-			declarePos, found = c.Parser.FindToken("enum")
+		var declarePos int
+		for _, keyword := range classKeywords {
+			if declarePos, found = c.Parser.FindToken(keyword); found {
+				break
+			}
 		}
 		if !found {
 			continue
 		}
 
 		c.ClassName = c.Parser.GetToken(declarePos + 1)
+		c.Line = c.Parser.GetCurrentStatement()
 
-		if strings.Contains(c.ClassName, "<") {
-			continue
+		if i := strings.Index(c.ClassName, "<"); i >= 0 {
+			var err error
+			if c.TypeParams, err = ParseTypeParams(c.ClassName[i:]); err != nil {
+				return c.errorf("%s", err)
+			}
+			c.ClassName = c.ClassName[:i]
 		}
 
         if pos, found := c.Parser.FindToken("extends"); found  {
             c.Extends = c.Parser.GetToken(pos +1 )
+            if err := c.normalizeType(&c.Extends); err != nil {
+                return err
+            }
         }
+		_, abstract := c.Parser.FindToken("abstract")
+		c.Kind = classKind(c.Parser.GetToken(declarePos), abstract, c.Extends)
 
+		// interfaces extend the interfaces listed, classes implement them
+		listPos, found := c.Parser.FindToken("implements")
+		if c.Parser.GetToken(declarePos) == "interface" || c.Parser.GetToken(declarePos) == "@interface" {
+			listPos, found = c.Parser.FindToken("extends")
+		}
+		for i := listPos + 1; found && c.Parser.GetToken(i) != ""; i++ {
+			t := c.Parser.GetToken(i)
+			// implements follows extends, permits lists the subclasses of a sealed class
+			if t == "implements" || t == "permits" || t == "{" {
+				break
+			}
+			if err := c.normalizeType(&t); err != nil {
+				return err
+			}
+			c.Interfaces = append(c.Interfaces, t)
+		}
+		// a record declaration has the components, javap doesn't write it
+		if _, found := c.Parser.FindToken("("); found && c.Kind == Record {
+			params, err := (&SrcParams{c.Parser}).GetParams()
+			if err != nil {
+				return err
+			}
+			c.Components = make([]*ClassSigField, len(params))
+			for i, p := range params {
+				if err := c.normalizeType(&p.Type); err != nil {
+					return err
+				}
+				t := componentType(p.Type)
+				c.Components[i] = &ClassSigField{Name: p.Name, Type: t, Final: true, Line: t + " " + p.Name}
+			}
+		}
+
+		// javap writes the constants of an enum first, the fields of its
+		// type after another member are not constants
+		constants := c.Kind == Enum
 		for c.Parser.ScopeDepth() > 0 {
-			c.Parser.ParseStatement()
+			if err := c.Parser.ParseStatement(); err == io.EOF {
+				return c.errorf("%w in class %s", ErrUnexpectedEOF, c.ClassName)
+			} else if err != nil {
+				return err
+			}
 			if c.Parser.ScopeDepth() > 2 {
 				continue
 			}
@@ -362,57 +722,149 @@ func (c *ClassSig) Parse() {
 			}
 
 			_, static := c.Parser.FindToken("static")
-			_, fun := c.Parser.FindToken("(");
+			// a field initializer can have calls, a method has its ( first
+			parenPos, fun := c.Parser.FindToken("(");
+			for i := 0; fun && i < parenPos; i++ {
+				fun = !strings.Contains(c.Parser.GetToken(i), "=")
+			}
 			typePos := c.FirstNonKeyWord()
-			t := c.Parser.GetToken(typePos)
-			if t[0] == '<' && t[len(t) - 1] == '>' {
+			if typePos < 0 {
+				return c.errorf("declaration has no type")
+			}
+			var typeParams TypeParams
+			if t := c.Parser.GetToken(typePos); t[0] == '<' && t[len(t) - 1] == '>' {
+				var err error
+				if typeParams, err = ParseTypeParams(t); err != nil {
+					c.skip()
+					continue
+				}
+				typePos++
+			}
+
+			// nested classes are bound on their own, their members are
+			// not the class's
+			if classKeyword(c.Parser.GetToken(typePos)) {
+				constants = false
+				c.skip()
+				for depth := c.Parser.ScopeDepth(); c.Parser.ScopeDepth() >= depth; {
+					if err := c.Parser.ParseStatement(); err == io.EOF {
+						return c.errorf("%w in class %s", ErrUnexpectedEOF, c.ClassName)
+					} else if err != nil {
+						return err
+					}
+				}
 				continue
-//				typePos++
 			}
 
 			if fun {
+				params, err := c.Parser.GetParams()
+				if err != nil {
+					return err
+				}
+				ret := c.Parser.GetToken(typePos)
+				types := []*string{&ret}
+				for i := range params {
+					types = append(types, &params[i].Type)
+				}
+				constants = false
+				if !c.normalizeMember(types...) {
+					continue
+				}
+				params = typeParams.EraseParams(params)
 				if c.Parser.GetToken(typePos) == c.ClassName && !static  && c.Parser.GetToken(typePos+1) == "(" {
 					i := sliceutil.Append(&c.Constructors)
-					c.Constructors[i].Params = c.Parser.GetParams()
-					c.Constructors[i].Throws = c.Throws()
+					c.Constructors[i].Params = params
+					c.Constructors[i].Exceptions = typeParams.EraseAll(c.Exceptions())
+					c.Constructors[i].Throws = len(c.Constructors[i].Exceptions) > 0
 					c.Constructors[i].Line = c.Parser.GetCurrentStatement()
+					c.Constructors[i].TypeParams = typeParams
 				} else {
 					i := sliceutil.Append(&c.Methods)
 					c.Methods[i].Name = c.Parser.GetToken(typePos+1)
-					c.Methods[i].Params = c.Parser.GetParams()
-					c.Methods[i].Return = c.Parser.GetToken(typePos)
-					c.Methods[i].Throws = c.Throws()
+					c.Methods[i].Params = params
+					c.Methods[i].Return = typeParams.Erase(ret)
+					c.Methods[i].TypeParams = typeParams
+					c.Methods[i].Exceptions = typeParams.EraseAll(c.Exceptions())
+					c.Methods[i].Throws = len(c.Methods[i].Exceptions) > 0
 					c.Methods[i].Line = c.Parser.GetCurrentStatement()
 					c.Methods[i].Static = static
 				}
-			} else if static {
+			} else {
+				t := c.Parser.GetToken(typePos)
+				if !c.normalizeMember(&t) {
+					constants = false
+					continue
+				}
+				_, final := c.Parser.FindToken("final")
+				constants = constants && static && final && t == c.ClassName
 				i := sliceutil.Append(&c.Fields)
-				c.Fields[i].Name = c.Parser.GetToken(typePos+1)
-				c.Fields[i].Type = c.Parser.GetToken(typePos)
+				c.Fields[i].Name = strings.SplitN(c.Parser.GetToken(typePos+1), "=", 2)[0]
+				// javap -constants and source write "name = value"
+				if stmt := c.Parser.GetCurrentStatement(); static && final {
+					if j := strings.Index(stmt, "="); j >= 0 {
+						c.Fields[i].Value = strings.TrimSpace(stmt[j+1:])
+					}
+				}
+				c.Fields[i].Type = t
 				c.Fields[i].Static = static
+				c.Fields[i].Final = final
+				c.Fields[i].Enum = constants
+				c.Fields[i].Line = c.Parser.GetCurrentStatement()
 			}
 
 		}
 	}
 }
 
-func (c *ClassSig) Throws() bool {
-	var foundClose bool
+// normalizeType parses a type from the current statement and writes it back
+// the way javap does.
+func (c *ClassSig) normalizeType(t *string) error {
+	jt, err := ParseJavaType(*t)
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+	*t = jt.String()
+	return nil
+}
+
+// normalizeMember normalizes the types of the current member declaration,
+// which is skipped if one doesn't parse.
+func (c *ClassSig) normalizeMember(types ...*string) bool {
+	for _, t := range types {
+		if err := c.normalizeType(t); err != nil {
+			c.skip()
+			return false
+		}
+	}
+	return true
+}
+
+// skip records the current statement in Skipped.
+func (c *ClassSig) skip() {
+	c.Skipped = append(c.Skipped, c.Parser.GetCurrentStatement())
+}
+
+// Exceptions returns the classes in the throws clause of the current
+// statement.
+func (c *ClassSig) Exceptions() (exceptions []string) {
+	var foundClose, foundThrows bool
 	for i := 0; c.Parser.GetToken(i) != ""; i++ {
-		if c.Parser.GetToken(i) == ")" {
+		token := c.Parser.GetToken(i)
+		if foundThrows {
+			exceptions = append(exceptions, strings.TrimSuffix(token, ";"))
+		} else if token == ")" {
 			foundClose = true
-		}
-		if c.Parser.GetToken(i) == "throws" && foundClose {
-			return true
+		} else if token == "throws" && foundClose {
+			foundThrows = true
 		}
 	}
-	return false
+	return
 }
 
-func (c *ClassSig) ParamWords() (count int, start int) {
+func (c *ClassSig) ParamWords() (count int, start int, err error) {
 	start, found := c.Parser.FindToken("(")
 	if !found {
-		panic("ParamWords called while invalid statement")
+		return 0, 0, c.errorf("expected (")
 	}
 	start++
 	i := start
@@ -421,6 +873,9 @@ func (c *ClassSig) ParamWords() (count int, start int) {
 		if token == ")" {
 			break
 		}
+		if token == "" {
+			return 0, 0, c.errorf("expected )")
+		}
 		if !javaKeyWord(token) {
 			count++
 		}
@@ -450,6 +905,10 @@ func (c *ClassSig) GetFields() []*ClassSigField {
 	return c.Fields
 }
 
+func (c *ClassSig) GetComponents() []*ClassSigField {
+	return c.Components
+}
+
 type ClassSigFilter struct {
 	Parser
 	filter map[string]byte
@@ -463,15 +922,33 @@ func NewClassSigFilter(p Parser, filterStr string) *ClassSigFilter {
 	return &ClassSigFilter{p, filter}
 }
 
+// filtered reports whether a type refers to a filtered name, anywhere in
+// its type arguments. Primitive types and "[]" or "..." for arrays can be
+// filtered as well as class names.
+func (c *ClassSigFilter) filtered(t string) bool {
+	jt := parseJavaTypeName(t)
+	names := jt.ClassNames()
+	if jt.Kind == PrimitiveType {
+		names = append(names, jt.Name)
+	}
+	if jt.IsArray() {
+		names = append(names, jt.Components()[0])
+	}
+	for _, name := range names {
+		if _, ok := c.filter[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *ClassSigFilter) GetConstructors() []*ClassSigConstructor {
 	ret := make([]*ClassSigConstructor, 0, len(c.Parser.GetConstructors()))
 A:
 	for _, v := range c.Parser.GetConstructors() {
 		for _, v2 := range v.Params {
-			for _, v3 := range JavaTypeComponents(v2.Type) {
-				if _, ok := c.filter[v3]; ok {
-					continue A
-				}
+			if c.filtered(v2.Type) {
+				continue A
 			}
 		}
 		ret = append(ret, v)
@@ -484,17 +961,13 @@ func (c *ClassSigFilter) GetMethods() []*ClassSigMethod {
 A:
 	for _, v := range c.Parser.GetMethods() {
 		for _, v2 := range v.Params {
-			for _, v3 := range JavaTypeComponents(v2.Type) {
-				if _, ok := c.filter[v3]; ok {
-					continue A
-				}
-			}
-		}
-		for _, v2 := range JavaTypeComponents(v.Return) {
-			if _, ok := c.filter[v2]; ok {
+			if c.filtered(v2.Type) {
 				continue A
 			}
 		}
+		if c.filtered(v.Return) {
+			continue A
+		}
 		ret = append(ret, v)
 	}
 	return ret
@@ -503,7 +976,18 @@ A:
 func (c *ClassSigFilter) GetFields() []*ClassSigField {
 	ret := make([]*ClassSigField, 0, len(c.Parser.GetFields()))
 	for _, v := range c.Parser.GetFields() {
-		if _, ok := c.filter[v.Type]; ok {
+		if c.filtered(v.Type) {
+			continue
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+func (c *ClassSigFilter) GetInterfaces() []string {
+	ret := make([]string, 0, len(c.Parser.GetInterfaces()))
+	for _, v := range c.Parser.GetInterfaces() {
+		if c.filtered(v) {
 			continue
 		}
 		ret = append(ret, v)
@@ -512,10 +996,8 @@ func (c *ClassSigFilter) GetFields() []*ClassSigField {
 }
 
 func (c *ClassSigFilter) GetExtends() string {
-    for _, v := range JavaTypeComponents(c.Parser.GetExtends()) {
-        if _, ok := c.filter[v]; ok {
-            return ""
-        }
+    if c.filtered(c.Parser.GetExtends()) {
+        return ""
     }
 
     return c.Parser.GetExtends()
@@ -525,8 +1007,11 @@ type SrcParams struct {
 	Parser Parser
 }
 
-func (c *SrcParams) GetParams() Params {
-	paramLen, startToken := c.Parser.ParamWords()
+func (c *SrcParams) GetParams() (Params, error) {
+	paramLen, startToken, err := c.Parser.ParamWords()
+	if err != nil {
+		return nil, err
+	}
 	paramLen = paramLen / 2
 
 	params := make(Params, paramLen)
@@ -539,18 +1024,21 @@ func (c *SrcParams) GetParams() Params {
 		if params[i].Name == "..." {
 			startToken++
 			params[i].Name =  c.Parser.GetToken(startToken+1 + i*2)
-			params[i].Type = "..." + params[i].Type
+			params[i].Type = params[i].Type + "..."
 		}
 	}
-	return params
+	return params, nil
 }
 
 type JavapParams struct {
 	Parser Parser
 }
 
-func (c *JavapParams) GetParams() Params {
-	paramLen, startToken := c.Parser.ParamWords()
+func (c *JavapParams) GetParams() (Params, error) {
+	paramLen, startToken, err := c.Parser.ParamWords()
+	if err != nil {
+		return nil, err
+	}
 	params := make(Params, paramLen)
 	r := 'a'
 	for i := range params {	
@@ -558,7 +1046,19 @@ func (c *JavapParams) GetParams() Params {
 		params[i].Type =  c.Parser.GetToken(startToken + i)
 		r++
 	}
-	return params
+	return params, nil
+}
+
+// classKeywords start the declaration of a class of each kind.
+var classKeywords = []string{"class", "interface", "enum", "record", "@interface"}
+
+func classKeyword(w string) bool {
+	for _, keyword := range classKeywords {
+		if w == keyword {
+			return true
+		}
+	}
+	return false
 }
 
 var javaKeyWords = map[string]bool {
@@ -566,6 +1066,9 @@ var javaKeyWords = map[string]bool {
 	"static":true,
 	"abstract":true,
 	"public":true,
+	"default":true,
+	"volatile":true,
+	"transient":true,
 }
 
 func javaKeyWord(w string) bool {
@@ -575,6 +1078,25 @@ func javaKeyWord(w string) bool {
 	return false
 }
 
+// ParseClass parses javap output for a single class. Each call uses its own
+// parser so it can be called concurrently.
+func ParseClass(r io.Reader) (*ClassSig, error) {
+	handle := &ParserHandle{}
+	sig := &ClassSig{Parser: handle}
+	parser := NewParser(
+		handle,
+		NewStatements(handle),
+		&Tokens{Parser: handle},
+		sig,
+		&JavapParams{Parser: handle},
+		commentfilter.NewCommentFilter("Signature:", "\n", `"`, `\`, commentfilter.NewCommentFilter("Compiled from", "\n", `"`, `\`, r)),
+	)
+	if err := parser.Scan(); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
 func NewParser(h *ParserHandle, s *Statements, t *Tokens, c *ClassSig, p ParamParser, r io.Reader) Parser {
 	o := &struct {
 		*Statements
@@ -1,6 +1,10 @@
 package jag
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -10,3 +14,263 @@ func TestJavaTypeParse(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestParseEOF(t *testing.T) {
+	sig, err := ParseClass(strings.NewReader("public class local.Foo {\n  public local.Foo();\n  public int bar(int);\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.ClassName != "local.Foo" || len(sig.Constructors) != 1 || len(sig.Methods) != 1 {
+		t.Fatalf("%+v", sig)
+	}
+
+	_, err = ParseClass(strings.NewReader("public class local.Foo {\n  public local.Foo();\n"))
+	if !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("got %v, want %v", err, ErrUnexpectedEOF)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := ParseClass(strings.NewReader("public class local.Foo {\n  public local.Foo();\n  public int bar(int;\n}\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("got %v, want ParseError", err)
+	}
+	if parseErr.Line != 3 || parseErr.Column != 3 || parseErr.Statement != "public int bar(int" {
+		t.Fatalf("%+v", parseErr)
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	sig, err := ParseClass(strings.NewReader("public class local.Foo {\n  public int[ bad();\n  public int count;\n  public java.lang.String name(java.lang.String[);\n  public int good();\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.Methods) != 1 || sig.Methods[0].Name != "good" || len(sig.Fields) != 1 {
+		t.Fatalf("%+v", sig)
+	}
+	if want := []string{"public int[ bad()", "public java.lang.String name(java.lang.String[)"}; !reflect.DeepEqual(sig.Skipped, want) {
+		t.Fatalf("got %q, want %q", sig.Skipped, want)
+	}
+
+	// nested classes are skipped with their members
+	sig, err = parseSource(`package local;
+public class Foo {
+	public static class Inner {
+		public int inner;
+	}
+	public interface Listener { void changed(); }
+	public int outer;
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.Fields) != 1 || sig.Fields[0].Name != "outer" {
+		t.Fatalf("%+v", sig.Fields)
+	}
+	if want := []string{"public static class Inner", "public interface Listener"}; !reflect.DeepEqual(sig.Skipped, want) {
+		t.Fatalf("got %q, want %q", sig.Skipped, want)
+	}
+}
+
+func TestParseClassConcurrent(t *testing.T) {
+	errs := make(chan error)
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			name := fmt.Sprintf("local.Foo%d", i)
+			sig, err := ParseClass(strings.NewReader("public class " + name + " {\n  public " + name + "(int);\n}\n"))
+			if err == nil && (sig.ClassName != name || len(sig.Constructors) != 1) {
+				err = fmt.Errorf("%+v", sig)
+			}
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestClassKind(t *testing.T) {
+	for _, v := range []struct {
+		decl string
+		kind ClassKind
+	}{
+		{"public class local.Foo", Class},
+		{"public abstract class local.Foo", AbstractClass},
+		{"public interface local.Foo", Interface},
+		{"public interface local.Foo extends java.lang.annotation.Annotation", Annotation},
+		{"public final class local.Foo extends java.lang.Enum<local.Foo>", Enum},
+		{"public abstract class local.Foo extends java.lang.Enum<local.Foo>", Enum},
+		{"public final class local.Foo extends java.lang.Record", Record},
+		{"public enum Foo", Enum},
+		{"public record Foo(int x, int y)", Record},
+		{"public @interface Foo", Annotation},
+	} {
+		sig, err := ParseClass(strings.NewReader(v.decl + " {\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Kind != v.kind {
+			t.Errorf("%s: got %s, want %s", v.decl, sig.Kind, v.kind)
+		}
+	}
+}
+
+func TestAbstractClassList(t *testing.T) {
+	list := NewAbstractClassList(strings.NewReader("local.Listed\n-local.Shape\n"))
+	list.AddClass("local.Shape", AbstractClass)
+	list.AddClass("local.Runnable", Interface)
+	list.AddClass("local.Foo", Class)
+	for name, want := range map[string]bool{
+		"local.Listed":   true,
+		"local.Shape":    false,
+		"local.Runnable": true,
+		"local.Foo":      false,
+		"local.Unknown":  false,
+	} {
+		if list.IsAbstractClass(name) != want {
+			t.Errorf("%s: got %v", name, !want)
+		}
+	}
+}
+
+func TestRecordComponents(t *testing.T) {
+	for _, v := range []struct {
+		javap string
+		want  string
+	}{
+		{`public final class local.Point extends java.lang.Record {
+  public local.Point(int, java.lang.String, int...);
+  public final java.lang.String toString();
+  public final int hashCode();
+  public final boolean equals(java.lang.Object);
+  public int x();
+  public java.lang.String label();
+  public int[] rest();
+  public int area();
+  public static local.Point origin();
+}`, "int x, java.lang.String label, int[] rest"},
+		{`public final class local.Point extends java.lang.Record {
+  public local.Point(int, int);
+  public local.Point(int);
+  public int x();
+  public int y();
+}`, "int x, int y"},
+		{"public record local.Point(int x, java.util.List<java.lang.String> names) {\n}", "int x, java.util.List<java.lang.String> names"},
+		{"public record local.Empty() {\n}", ""},
+	} {
+		sig, err := ParseClass(strings.NewReader(v.javap + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range sig.Components {
+			got = append(got, c.Line)
+		}
+		if sig.Components == nil || strings.Join(got, ", ") != v.want {
+			t.Errorf("got %q, want %q", got, v.want)
+		}
+	}
+}
+
+func TestInnerClass(t *testing.T) {
+	for _, v := range []struct {
+		javap string
+		outer string
+	}{
+		{"public class local.Outer$Inner {\n  public local.Outer$Inner(local.Outer, int);\n}", "local.Outer"},
+		{"public class local.Outer$Nested {\n  public local.Outer$Nested(int);\n}", ""},
+		{"public interface local.Outer$Listener {\n}", ""},
+		{"public class local.Outer {\n  public local.Outer(local.Outer);\n}", ""},
+	} {
+		sig, err := ParseClass(strings.NewReader(v.javap + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Outer != v.outer {
+			t.Errorf("%s: got outer %q, want %q", sig.ClassName, sig.Outer, v.outer)
+		}
+		if v.outer != "" && sig.Constructors[0].Params[0].Name != "outer" {
+			t.Errorf("%s: bad params %v", sig.ClassName, sig.Constructors[0].Params)
+		}
+	}
+
+	for name, want := range map[string]bool{
+		"local.Outer$Inner":         false,
+		"local.Outer$1":             true,
+		"local.Outer$1Local":        true,
+		"local/Outer$Inner$2.class": true,
+		"local.Outer":               false,
+	} {
+		if IsAnonymousClass(name) != want {
+			t.Errorf("%s: got anonymous %v", name, !want)
+		}
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	for _, v := range []struct {
+		decl       string
+		extends    string
+		interfaces string
+	}{
+		{"public class local.Foo extends local.Bar<java.lang.String> implements java.lang.Runnable, java.lang.Comparable<local.Foo>", "local.Bar<java.lang.String>", "java.lang.Runnable java.lang.Comparable<local.Foo>"},
+		{"public class local.Foo implements java.util.Map<java.lang.String, java.lang.Integer>", "", "java.util.Map<java.lang.String, java.lang.Integer>"},
+		{"public interface local.A extends local.B, local.C<java.lang.String>", "local.B", "local.B local.C<java.lang.String>"},
+		{"public class local.Foo", "", ""},
+		{"public abstract sealed class local.Shape implements java.lang.Comparable<local.Shape> permits local.Circle,local.Square", "", "java.lang.Comparable<local.Shape>"},
+		{"public sealed interface local.Shape extends local.Named permits local.Circle, local.Square", "local.Named", "local.Named"},
+		{"public sealed interface local.Shape permits local.Circle, local.Square", "", ""},
+	} {
+		sig, err := ParseClass(strings.NewReader(v.decl + " {\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Extends != v.extends || strings.Join(sig.Interfaces, " ") != v.interfaces {
+			t.Errorf("%s: got extends %q interfaces %q", v.decl, sig.Extends, sig.Interfaces)
+		}
+	}
+}
+
+// parseSource parses Java source the way jagen -src does.
+func parseSource(src string) (*ClassSig, error) {
+	handle := &ParserHandle{}
+	sig := &ClassSig{Parser: handle}
+	parser := NewParser(handle, NewStatements(handle), &Tokens{Parser: handle}, sig, &SrcParams{Parser: handle}, strings.NewReader(src))
+	if err := parser.Scan(); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func TestSourceAnnotations(t *testing.T) {
+	sig, err := parseSource(`package local;
+@FunctionalInterface public class Foo {
+	@Deprecated @SuppressWarnings({"unchecked", "rawtypes"})
+	public void put(@Deprecated java.util.Map<String,Integer> m, @Size(max = 5, message = "(") final String s) {
+	}
+	@Nullable public String name;
+	public @interface Marker {
+	}
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.Methods) != 1 || len(sig.Fields) != 1 {
+		t.Fatalf("%+v", sig)
+	}
+	m := sig.Methods[0]
+	if m.Name != "put" || strings.Join(m.Params.Names(), " ") != "m s" || strings.Join(m.Params.Types(), " ") != "java.util.Map<String, Integer> String" {
+		t.Errorf("got %s(%v)", m.Name, m.Params)
+	}
+	if m.Line != "public void put(java.util.Map<String,Integer> m, final String s)" {
+		t.Errorf("got line %q", m.Line)
+	}
+	if sig.Fields[0].Name != "name" {
+		t.Errorf("got field %+v", sig.Fields[0])
+	}
+}
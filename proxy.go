@@ -0,0 +1,175 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// proxyMethodKey returns the method as jagrt.Invocation.Method reports it,
+// name(type,type) with the types erased.
+func (s *StringGenerator) proxyMethodKey(method *ClassSigMethod) string {
+	types := make([]string, len(method.Params))
+	for i, p := range method.Params {
+		types[i] = parseJavaTypeName(s.Gen.JavaErasure(p.Type)).Raw().String()
+	}
+	return method.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// indent runs f and indents what it writes by prefix.
+func (s *StringGenerator) indent(prefix string, f func()) {
+	out := s.out
+	s.out = ""
+	f()
+	for _, line := range strings.SplitAfter(s.out, "\n") {
+		if line != "" && line != "\n" {
+			line = prefix + line
+		}
+		out += line
+	}
+	s.out = out
+}
+
+// gojvmTypeMethod writes a GoJVM type the way GoProxy names its methods for
+// it, eg "int[]" as "intArray".
+func gojvmTypeMethod(t string) string {
+	return strings.Replace(t, "[]", "Array", -1)
+}
+
+// GenerateProxyArg writes the conversion of argument i of an invocation to
+// the Go variable arg_<name>.
+func (s *StringGenerator) GenerateProxyArg(i int, p Param) {
+	name := "arg_" + p.Name
+	if s.Gen.IsGoJVMType(p.Type) {
+		t := gojvmTypeMethod(p.Type)
+		s.out += fmt.Sprintf("\t%s, err := inv.Call%s(\"%sArg\", %d)\n", name, capitalize(t), t, i)
+		s.out += "\tif err != nil {\n\t\treturn err\n\t}\n"
+		return
+	}
+	s.out += fmt.Sprintf("\tj%s, err := inv.CallObj(\"arg\", \"java.lang.Object\", %d)\n", name, i)
+	s.out += "\tif err != nil {\n\t\treturn err\n\t}\n"
+	s.out += "\tconv_" + name + " := " + s.Gen.ConverterForType(javaToGoPrefix, p.Type) + "\n"
+	callable := s.Gen.IsCallableType(JavaTypeComponents(p.Type)[0])
+	goType := s.Gen.JavaToGoTypeName(p.Type)
+	if callable {
+		s.out += "\tdst_" + name + " := &javabind.Callable{}\n"
+	} else {
+		s.out += "\tdst_" + name + " := new(" + goType + ")\n"
+	}
+	s.out += "\tconv_" + name + ".Dest(dst_" + name + ")\n"
+	s.out += "\tif err := conv_" + name + ".Convert(j" + name + "); err != nil {\n\t\treturn err\n\t}\n"
+	s.out += "\tconv_" + name + ".CleanUp()\n"
+	if callable {
+		s.out += "\t" + name + " := &" + strings.TrimPrefix(goType, "*") + "{}\n"
+		s.out += "\t" + name + ".Callable = dst_" + name + "\n"
+	} else {
+		s.out += "\t" + name + " := *dst_" + name + "\n"
+	}
+}
+
+// GenerateProxyReturn writes the completion of an invocation with the Go
+// value ret.
+func (s *StringGenerator) GenerateProxyReturn(jtype string) {
+	if jtype == "void" {
+		s.out += "\tif err := inv.CallVoid(\"completeVoid\"); err != nil {\n\t\treturn err\n\t}\n"
+	} else if s.Gen.IsGoJVMType(jtype) {
+		s.out += "\tif err := inv.CallVoid(\"complete" + capitalize(gojvmTypeMethod(jtype)) + "\", ret); err != nil {\n\t\treturn err\n\t}\n"
+	} else {
+		params := Params{{"ret", jtype}}
+		s.GenerateParamConversion(params, "return err")
+		s.out += "\tif err := inv.CallVoid(\"complete\", javabind.CastObject(conv_ret.Value(), \"java.lang.Object\")); err != nil {\n\t\treturn err\n\t}\n"
+		s.GenerateParamConversionCleanup(params)
+	}
+}
+
+// dispatchFuncName returns the name of the function GenerateProxy writes to
+// dispatch the calls of the Go type of an interface, which can be in another
+// package, eg util.DispatchFoo[T] for *util.Foo[T].
+func dispatchFuncName(goType string) string {
+	goType = strings.TrimPrefix(goType, "*")
+	name := goType
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	i := strings.LastIndex(name, ".") + 1
+	return goType[:i] + "Dispatch" + goType[i:]
+}
+
+// GenerateProxy writes a function that returns a Java object implementing
+// the interface by calling a Go implementation of its Go interface. It uses
+// the jagrt.GoProxy Java class. The calls are dispatched by a function for
+// the interface, which hands the methods it doesn't declare to those of the
+// interfaces it extends: the one whose Go interface it embeds, and the others
+// when impl implements their Go interfaces.
+func (s *StringGenerator) GenerateProxy(goClassTypeName, goClassType, goTypeParams, goTypeArgs, extends string, methodNames []string) {
+	sig := s.Gen.GetClassSignature()
+	funcName := "New" + goClassTypeName + "Proxy"
+	dispatchName := dispatchFuncName(goClassTypeName)
+	interfaceType := interfaceTypeName(goClassType)
+
+	s.out += fmt.Sprintf("// %s calls the method of impl an invocation is for, it returns\n// jagrt.ErrNoMethod for methods %s doesn't have.\n", dispatchName, sig.GetClassName())
+	s.out += fmt.Sprintf("func %s%s(impl %s, inv *jagrt.Invocation, method string) error {\n", dispatchName, goTypeParams, interfaceType)
+	s.out += "\tswitch method {\n"
+	keys := make(map[string]bool)
+	for i, method := range sig.GetMethods() {
+		key := s.proxyMethodKey(method)
+		if method.Static || keys[key] {
+			continue
+		}
+		keys[key] = true
+		s.out += "\tcase \"" + key + "\":\n"
+		s.indent("\t", func() {
+			args := make([]string, len(method.Params))
+			for j, p := range method.Params {
+				s.GenerateProxyArg(j, p)
+				args[j] = "arg_" + p.Name
+			}
+			call := "impl." + methodNames[i] + "(" + strings.Join(args, ", ") + ")"
+			ret := s.Gen.JavaToGoTypeName(method.Return)
+			returnsError := s.returnsError(method.Throws)
+			if ret != "" && returnsError {
+				s.out += "\tret, err := " + call + "\n\tif err != nil {\n\t\treturn err\n\t}\n"
+			} else if ret != "" {
+				s.out += "\tret := " + call + "\n"
+			} else if returnsError {
+				s.out += "\tif err := " + call + "; err != nil {\n\t\treturn err\n\t}\n"
+			} else {
+				s.out += "\t" + call + "\n"
+			}
+			s.GenerateProxyReturn(method.Return)
+			s.out += "\treturn nil\n"
+		})
+	}
+	s.out += "\t}\n"
+	for _, iface := range s.superInterfaces() {
+		goType := s.Gen.JavaToGoTypeName(iface)
+		dispatch := dispatchFuncName(goType)
+		if iface == extends {
+			s.out += fmt.Sprintf("\tif err := %s(impl, inv, method); err != jagrt.ErrNoMethod {\n\t\treturn err\n\t}\n", dispatch)
+			continue
+		}
+		s.out += fmt.Sprintf("\tif impl, ok := impl.(%s); ok {\n", interfaceTypeName(goType))
+		s.out += fmt.Sprintf("\t\tif err := %s(impl, inv, method); err != jagrt.ErrNoMethod {\n\t\t\treturn err\n\t\t}\n\t}\n", dispatch)
+	}
+	s.out += "\treturn jagrt.ErrNoMethod\n}\n\n"
+
+	s.out += fmt.Sprintf("// %s returns a %s that calls impl, for passing\n", funcName, sig.GetClassName())
+	s.out += "// Go callbacks to Java. After release is called the Java object must not\n// be used.\n"
+	s.out += fmt.Sprintf("func %s%s(impl %s) (proxy *%s, release func()", funcName, goTypeParams, interfaceType, goClassType)
+	if s.NoPanic {
+		s.out += ", err error"
+	}
+	s.out += ") {\n"
+	s.out += fmt.Sprintf("\tobj, release, err := jagrt.NewProxy(\"%s\", func(inv *jagrt.Invocation) error {\n", sig.GetClassName())
+	s.out += "\t\tmethod, err := inv.Method()\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n"
+	s.out += fmt.Sprintf("\t\treturn %s%s(impl, inv, method)\n\t})\n", dispatchName, goTypeArgs)
+	if s.NoPanic {
+		s.out += "\tif err != nil {\n\t\treturn nil, nil, err\n\t}\n"
+	} else {
+		s.out += "\tif err != nil {\n\t\tpanic(err)\n\t}\n"
+	}
+	s.out += "\tproxy = &" + goClassType + "{}\n\tproxy.Callable = obj\n\treturn proxy, release"
+	if s.NoPanic {
+		s.out += ", nil"
+	}
+	s.out += "\n}\n\n"
+}
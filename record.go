@@ -0,0 +1,186 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkRecord finds the components of a record from its constructors and
+// methods when they were not declared or read from its class file.
+func (c *ClassSig) checkRecord() {
+	if c.Kind == Record && c.Components == nil {
+		c.Components = recordComponents(c.Constructors, c.Methods)
+	}
+}
+
+// recordComponents works out the components of a record from javap output,
+// which doesn't list them. They are the parameters of the canonical
+// constructor, named by the accessor methods, which javac declares in
+// component order. It returns nil if no constructor matches the accessors.
+func recordComponents(constructors []*ClassSigConstructor, methods []*ClassSigMethod) (components []*ClassSigField) {
+	var accessors []*ClassSigMethod
+	for _, m := range methods {
+		if !m.Static && len(m.Params) == 0 && len(m.TypeParams) == 0 && m.Return != "" && m.Return != "void" &&
+			m.Name != "toString" && m.Name != "hashCode" {
+			accessors = append(accessors, m)
+		}
+	}
+	// the constructor with the most parameters matching accessors in order
+	for _, constructor := range constructors {
+		if components != nil && len(constructor.Params) <= len(components) {
+			continue
+		}
+		found := make([]*ClassSigField, 0, len(constructor.Params))
+		j := 0
+		for _, p := range constructor.Params {
+			t := componentType(p.Type)
+			for j < len(accessors) && accessors[j].Return != t {
+				j++
+			}
+			if j == len(accessors) {
+				break
+			}
+			found = append(found, &ClassSigField{
+				Name:  accessors[j].Name,
+				Type:  t,
+				Final: true,
+				Line:  t + " " + accessors[j].Name,
+				Doc:   accessors[j].Doc,
+			})
+			j++
+		}
+		if len(found) == len(constructor.Params) {
+			components = found
+		}
+	}
+	return
+}
+
+// componentType returns the type of a record component from the type of the
+// canonical constructor parameter, varargs are arrays.
+func componentType(paramType string) string {
+	if strings.HasSuffix(paramType, "...") {
+		return strings.TrimSuffix(paramType, "...") + "[]"
+	}
+	return paramType
+}
+
+// canonicalConstructor returns the index of the constructor taking the
+// components of a record, -1 if there is none.
+func canonicalConstructor(components []*ClassSigField, constructors []*ClassSigConstructor) int {
+A:
+	for i, constructor := range constructors {
+		if len(constructor.Params) != len(components) {
+			continue
+		}
+		for j, p := range constructor.Params {
+			if componentType(p.Type) != components[j].Type {
+				continue A
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// GenerateRecord writes the Go type of a record, a struct with a field for
+// each component, and its converters. The methods of the record are on
+// goStructName, the Object method returns a new Java record for a value by
+// calling the canonical constructor, which has the name in constructorNames.
+func (s *StringGenerator) GenerateRecord(goClassTypeName, goStructName, goTypeParams, goTypeArgs string, constructorNames []string) {
+	sig := s.Gen.GetClassSignature()
+	goType := goClassTypeName + goTypeArgs
+	valueFunc := strings.ToLower(goClassTypeName[:1]) + goClassTypeName[1:] + "Value"
+
+	if sig.GetDoc() != "" {
+		s.printDoc(sig.GetDoc(), "")
+	} else {
+		s.out += fmt.Sprintf("// %s is the value of the Java record %s.\n", goClassTypeName, sig.GetClassName())
+	}
+	s.out += fmt.Sprintf("type %s%s struct {\n", goClassTypeName, goTypeParams)
+	for _, c := range sig.GetComponents() {
+		if c.Doc != "" {
+			for _, l := range strings.Split(c.Doc, "\n") {
+				s.out += strings.TrimRight("\t// "+l, " ") + "\n"
+			}
+		}
+		s.out += "\t"
+		s.printParams(Params{{capitalize(c.Name), c.Type}})
+		s.out += "\n"
+	}
+	s.out += "}\n\n"
+
+	// Object returns what the canonical constructor does
+	var objectErr string
+	canonical := canonicalConstructor(sig.GetComponents(), sig.GetConstructors())
+	if canonical >= 0 {
+		constructor := sig.GetConstructors()[canonical]
+		args := make([]string, len(sig.GetComponents()))
+		for i, c := range sig.GetComponents() {
+			args[i] = "r." + capitalize(c.Name)
+			if strings.HasSuffix(constructor.Params[i].Type, "...") {
+				args[i] += "..."
+			}
+		}
+		call := constructorNames[canonical] + goTypeArgs + "(" + strings.Join(args, ", ") + ")"
+		s.out += fmt.Sprintf("// Object returns a new Java %s with the components of r, to call its\n// methods.\n", sig.GetClassName())
+		s.out += fmt.Sprintf("func (r %s) Object() ", goType)
+		if s.returnsError(constructor.Throws) {
+			s.out += fmt.Sprintf("(*%s%s, error) {\n\treturn %s\n}\n\n", goStructName, goTypeArgs, call)
+			objectErr = "\t\tobj, err := value.(" + goType + ").Object()\n\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n"
+		} else {
+			s.out += fmt.Sprintf("*%s%s {\n\treturn %s\n}\n\n", goStructName, goTypeArgs, call)
+			objectErr = "\t\tobj := value.(" + goType + ").Object()\n"
+		}
+	}
+
+	s.out += fmt.Sprintf("// %s reads the components of a Java %s.\n", valueFunc, sig.GetClassName())
+	s.out += fmt.Sprintf("func %s%s(jbobject *javabind.Callable) (%s, error) {\n\tvar r %s\n", valueFunc, goTypeParams, goType, goType)
+	for _, c := range sig.GetComponents() {
+		s.generateComponent(c)
+	}
+	s.out += "\treturn r, nil\n}\n\n"
+
+	s.out += fmt.Sprintf("// NewGoToJava%s returns the converter of %s values to Java.\n", goClassTypeName, goClassTypeName)
+	s.out += fmt.Sprintf("func NewGoToJava%s%s() javabind.Converter {\n", goClassTypeName, goTypeParams)
+	s.out += "\treturn jagrt.NewGoToJavaRecord(func(value interface{}) (*javabind.Callable, error) {\n"
+	if canonical >= 0 {
+		s.out += objectErr + "\t\treturn obj.Callable, nil\n"
+	} else {
+		s.out += "\t\treturn nil, jagrt.ErrNoConstructor\n"
+	}
+	s.out += "\t})\n}\n\n"
+
+	s.out += fmt.Sprintf("// NewJavaToGo%s returns the converter of Java records to %s.\n", goClassTypeName, goClassTypeName)
+	s.out += fmt.Sprintf("func NewJavaToGo%s%s() javabind.Converter {\n", goClassTypeName, goTypeParams)
+	s.out += "\treturn jagrt.NewJavaToGoRecord(func(jbobject *javabind.Callable) (interface{}, error) {\n"
+	s.out += fmt.Sprintf("\t\treturn %s%s(jbobject)\n\t})\n}\n\n", valueFunc, goTypeArgs)
+
+	// the converters of a generic record depend on its type arguments
+	if goTypeParams == "" {
+		s.out += fmt.Sprintf("func init() {\n\tjagrt.RegisterConverter(%s{}, NewGoToJava%s, NewJavaToGo%s)\n}\n\n", goClassTypeName, goClassTypeName, goClassTypeName)
+	}
+}
+
+// generateComponent writes the block setting the field of r for a component
+// from its accessor.
+func (s *StringGenerator) generateComponent(c *ClassSigField) {
+	accessor := &ClassSigMethod{Name: c.Name, Return: c.Type}
+	out := s.out
+	s.out = "\tjret, err := "
+	s.GenerateFuncName(accessor)
+	s.out += "(\"" + c.Name + "\""
+	if !s.Gen.IsGoJVMType(c.Type) {
+		jretcomp := JavaTypeComponents(s.Gen.JavaErasure(c.Type))
+		comp := jretcomp[0]
+		if comp == "[]" {
+			comp = jretcomp[1]
+		}
+		s.out += ", \"" + comp + "\""
+	}
+	s.out += ")\n"
+	s.out += "\tif err != nil {\n\t\treturn r, jagrt.NewException(err)\n\t}\n"
+	s.GenerateResultConversion(c.Type, "return r, err", "r."+capitalize(c.Name)+" = ")
+	// each component is read in its own block, which is indented
+	s.out = out + "\t{\n\t" + strings.Replace(s.out, "\n\t", "\n\t\t", -1) + "\n\t}\n"
+}
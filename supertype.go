@@ -0,0 +1,52 @@
+package jag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// upcastName returns the name of the method returning an object as the Go
+// type of a Java supertype, eg AsRunnable for java.lang.Runnable.
+func upcastName(javaName string) string {
+	name := javaName[strings.LastIndex(javaName, ".")+1:]
+	return "As" + capitalize(strings.Replace(name, "$", "", -1))
+}
+
+// GenerateUpcasts writes a method for each interface the class implements,
+// other than the one its struct embeds, returning the object as the struct
+// of the interface, which has all its methods. The struct of the class only
+// satisfies the Go interface of an interface when it has all of them, which
+// it doesn't for default methods. Interfaces jagen doesn't know, from the
+// classes generated in the same run or the -abstract file, are left out. The
+// method gets the full Go name of the interface if funcNames has the short
+// one.
+func (s *StringGenerator) GenerateUpcasts(goClassType, extends string, funcNames map[string]bool) {
+	sig := s.Gen.GetClassSignature()
+	for _, iface := range sig.GetInterfaces() {
+		head := JavaTypeComponents(iface)[0]
+		if iface == extends || !s.Gen.IsCallableType(head) || !s.Gen.IsAbstractClass(head) {
+			continue
+		}
+		name := upcastName(head)
+		if funcNames[name] {
+			name = "As" + s.Gen.javaNameToGoName(head)
+		}
+		funcNames[name] = true
+		goType := s.Gen.JavaToGoTypeName(iface)
+		s.out += fmt.Sprintf("// %s returns the object as a %s.\n", name, iface)
+		s.out += fmt.Sprintf("func (jbobject *%s) %s() %s {\n", goClassType, name, goType)
+		s.out += fmt.Sprintf("\tx := &%s{}\n\tx.Callable = jbobject.Callable\n\treturn x\n}\n\n", strings.TrimPrefix(goType, "*"))
+	}
+}
+
+// superInterfaces returns the interfaces the class implements, or the
+// interface extends, that jagen knows, as for GenerateUpcasts.
+func (s *StringGenerator) superInterfaces() (list []string) {
+	for _, iface := range s.Gen.GetClassSignature().GetInterfaces() {
+		head := JavaTypeComponents(iface)[0]
+		if s.Gen.IsCallableType(head) && s.Gen.IsAbstractClass(head) {
+			list = append(list, iface)
+		}
+	}
+	return
+}